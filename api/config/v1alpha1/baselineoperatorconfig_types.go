@@ -0,0 +1,73 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	cfg "sigs.k8s.io/controller-runtime/pkg/config/v1alpha1"
+
+	perfv1 "github.com/josecastillolema/baseline-operator/api/v1"
+)
+
+// Guardrails are the operator-wide limits every Baseline must respect. The
+// limits set by the cluster BaselinePolicy take precedence
+type Guardrails struct {
+	// MaxCpuPerNode is the maximum number of cpu workers a Baseline can run on a node
+	MaxCpuPerNode *int32 `json:"maxCpuPerNode,omitempty"`
+	// MaxMemoryPerNode is the maximum virtual memory a Baseline can use on a node
+	MaxMemoryPerNode *resource.Quantity `json:"maxMemoryPerNode,omitempty"`
+	// ForbiddenNodeLabels are the labels of the nodes Baselines can not run
+	// on, added to the ones of the cluster BaselinePolicy
+	ForbiddenNodeLabels []perfv1.ForbiddenNodeLabel `json:"forbiddenNodeLabels,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// BaselineOperatorConfig is the configuration of the operator. The images,
+// the guardrails and the default tolerations are reloaded when the file
+// changes, the other fields require a restart
+type BaselineOperatorConfig struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// ControllerManagerConfigurationSpec configures the manager: metrics,
+	// health probes, webhook server and leader election
+	cfg.ControllerManagerConfigurationSpec `json:",inline"`
+
+	// DefaultImage is the stress-ng image of the Baselines that do not define one
+	DefaultImage string `json:"defaultImage,omitempty"`
+	// ToolsImage is the image of the HTTP and APIServer workloads
+	ToolsImage string `json:"toolsImage,omitempty"`
+	// ImageRegistry is the registry the default images are pulled from
+	// instead of their own
+	ImageRegistry string `json:"imageRegistry,omitempty"`
+	// WatchNamespaces restricts the operator to the Baselines of the
+	// namespaces, every namespace is watched if empty
+	WatchNamespaces []string `json:"watchNamespaces,omitempty"`
+	// MaxConcurrentReconciles is how many Baselines are reconciled at once
+	MaxConcurrentReconciles int `json:"maxConcurrentReconciles,omitempty"`
+	// Guardrails are the operator-wide limits of the Baselines
+	Guardrails *Guardrails `json:"guardrails,omitempty"`
+	// DefaultTolerations are the tolerations of the Baselines that do not
+	// define any
+	DefaultTolerations []corev1.Toleration `json:"defaultTolerations,omitempty"`
+}
+
+func init() {
+	SchemeBuilder.Register(&BaselineOperatorConfig{})
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// controlPlaneTaints are the taints of the control plane nodes, which the
+// default tolerations must not tolerate: the Baselines not allowing the
+// control plane would be reported as degraded
+var controlPlaneTaints = []string{
+	"node-role.kubernetes.io/control-plane",
+	"node-role.kubernetes.io/master",
+}
+
+// Validate returns the invalid fields of the configuration
+func (c *BaselineOperatorConfig) Validate() error {
+	var errs field.ErrorList
+	for _, image := range []struct {
+		name, value string
+	}{{"defaultImage", c.DefaultImage}, {"toolsImage", c.ToolsImage}, {"imageRegistry", c.ImageRegistry}} {
+		if strings.ContainsAny(image.value, " \t\n") || strings.Contains(image.value, "://") {
+			errs = append(errs, field.Invalid(field.NewPath(image.name), image.value, "must be an image reference"))
+		}
+	}
+
+	seen := map[string]bool{}
+	for i, ns := range c.WatchNamespaces {
+		path := field.NewPath("watchNamespaces").Index(i)
+		for _, msg := range validation.IsDNS1123Label(ns) {
+			errs = append(errs, field.Invalid(path, ns, msg))
+		}
+		if seen[ns] {
+			errs = append(errs, field.Duplicate(path, ns))
+		}
+		seen[ns] = true
+	}
+	if c.MaxConcurrentReconciles < 0 {
+		errs = append(errs, field.Invalid(field.NewPath("maxConcurrentReconciles"), c.MaxConcurrentReconciles, "must not be negative"))
+	}
+
+	if g := c.Guardrails; g != nil {
+		path := field.NewPath("guardrails")
+		if g.MaxCpuPerNode != nil && *g.MaxCpuPerNode < 1 {
+			errs = append(errs, field.Invalid(path.Child("maxCpuPerNode"), *g.MaxCpuPerNode, "must be at least 1"))
+		}
+		if g.MaxMemoryPerNode != nil && g.MaxMemoryPerNode.Sign() <= 0 {
+			errs = append(errs, field.Invalid(path.Child("maxMemoryPerNode"), g.MaxMemoryPerNode.String(), "must be positive"))
+		}
+		for i, l := range g.ForbiddenNodeLabels {
+			for _, msg := range validation.IsQualifiedName(l.Key) {
+				errs = append(errs, field.Invalid(path.Child("forbiddenNodeLabels").Index(i).Child("key"), l.Key, msg))
+			}
+		}
+	}
+
+	for i, t := range c.DefaultTolerations {
+		path := field.NewPath("defaultTolerations").Index(i)
+		switch t.Operator {
+		case corev1.TolerationOpEqual, "":
+		case corev1.TolerationOpExists:
+			if t.Value != "" {
+				errs = append(errs, field.Invalid(path.Child("value"), t.Value, "must be empty when operator is Exists"))
+			}
+		default:
+			errs = append(errs, field.NotSupported(path.Child("operator"), t.Operator, []string{string(corev1.TolerationOpEqual), string(corev1.TolerationOpExists)}))
+		}
+		switch t.Effect {
+		case corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute, "":
+		default:
+			errs = append(errs, field.NotSupported(path.Child("effect"), t.Effect, []string{string(corev1.TaintEffectNoSchedule), string(corev1.TaintEffectPreferNoSchedule), string(corev1.TaintEffectNoExecute)}))
+		}
+		if t.Key == "" && t.Operator != corev1.TolerationOpExists {
+			errs = append(errs, field.Invalid(path.Child("operator"), t.Operator, "must be Exists when key is empty"))
+		}
+		if t.Key == "" && t.Operator == corev1.TolerationOpExists {
+			errs = append(errs, field.Invalid(path.Child("key"), t.Key, "must not tolerate every taint, including the control plane ones"))
+		}
+		for _, taint := range controlPlaneTaints {
+			if t.Key == taint {
+				errs = append(errs, field.Invalid(path.Child("key"), t.Key, "must not tolerate the control plane nodes"))
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid operator configuration: %w", errs.ToAggregate())
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+//go:build !ignore_autogenerated
+// +build !ignore_autogenerated
+
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by controller-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	"github.com/josecastillolema/baseline-operator/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+)
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BaselineOperatorConfig) DeepCopyInto(out *BaselineOperatorConfig) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ControllerManagerConfigurationSpec.DeepCopyInto(&out.ControllerManagerConfigurationSpec)
+	if in.WatchNamespaces != nil {
+		in, out := &in.WatchNamespaces, &out.WatchNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Guardrails != nil {
+		in, out := &in.Guardrails, &out.Guardrails
+		*out = new(Guardrails)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DefaultTolerations != nil {
+		in, out := &in.DefaultTolerations, &out.DefaultTolerations
+		*out = make([]corev1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BaselineOperatorConfig.
+func (in *BaselineOperatorConfig) DeepCopy() *BaselineOperatorConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(BaselineOperatorConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BaselineOperatorConfig) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Guardrails) DeepCopyInto(out *Guardrails) {
+	*out = *in
+	if in.MaxCpuPerNode != nil {
+		in, out := &in.MaxCpuPerNode, &out.MaxCpuPerNode
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxMemoryPerNode != nil {
+		in, out := &in.MaxMemoryPerNode, &out.MaxMemoryPerNode
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.ForbiddenNodeLabels != nil {
+		in, out := &in.ForbiddenNodeLabels, &out.ForbiddenNodeLabels
+		*out = make([]v1.ForbiddenNodeLabel, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Guardrails.
+func (in *Guardrails) DeepCopy() *Guardrails {
+	if in == nil {
+		return nil
+	}
+	out := new(Guardrails)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Args returns the custom arguments of the spec. The deprecated Custom
+// string takes precedence over CustomArgs, as the webhook converts it on
+// every write
+func (s *BaselineSpec) Args() ([]string, error) {
+	if s.Custom != "" {
+		return SplitArgs(s.Custom)
+	}
+	return s.CustomArgs, nil
+}
+
+// IoWorkers returns the number of io workers of the spec, 0 if unset
+func (s *BaselineSpec) IoWorkers() int32 {
+	if s.Io == nil {
+		return 0
+	}
+	return *s.Io
+}
+
+// SockWorkers returns the number of sock workers of the spec, 0 if unset
+func (s *BaselineSpec) SockWorkers() int32 {
+	if s.Sock == nil {
+		return 0
+	}
+	return *s.Sock
+}
+
+// UsesHostNetwork returns whether the pods of the spec use the host network
+func (s *BaselineSpec) UsesHostNetwork() bool {
+	return s.HostNetwork != nil && *s.HostNetwork
+}
+
+// SplitArgs splits a command line into arguments the way a POSIX shell does:
+// arguments are separated by blanks, and single quotes, double quotes and
+// backslashes escape them. Within double quotes, a backslash only escapes
+// $, `, ", \ and newline, and is kept before any other character
+func SplitArgs(line string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inArg := false
+	var quote rune
+	escaped := false
+	for _, c := range line {
+		switch {
+		case escaped:
+			// A backslash before a newline continues the line
+			if c != '\n' {
+				if quote == '"' && !strings.ContainsRune("$`\"\\", c) {
+					current.WriteRune('\\')
+				}
+				current.WriteRune(c)
+			}
+			escaped = false
+		case quote == '\'':
+			if c == '\'' {
+				quote = 0
+			} else {
+				current.WriteRune(c)
+			}
+		case quote == '"':
+			switch c {
+			case '"':
+				quote = 0
+			case '\\':
+				escaped = true
+			default:
+				current.WriteRune(c)
+			}
+		case c == '\'' || c == '"':
+			quote = c
+			inArg = true
+		case c == '\\':
+			escaped = true
+			inArg = true
+		case c == ' ' || c == '\t' || c == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(c)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote in %q", quote, line)
+	}
+	if escaped {
+		return nil, fmt.Errorf("trailing backslash in %q", line)
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
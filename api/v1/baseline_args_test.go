@@ -0,0 +1,63 @@
+package v1
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Baseline custom arguments", func() {
+
+	Context("Splitting a custom string", func() {
+		It("Should honour blanks, quotes and escapes", func() {
+			args, err := SplitArgs(`  --timer 1   --exec-method 'execve  vfork' --msg "a \"b\" c" --x\ y ''`)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(args).Should(Equal([]string{"--timer", "1", "--exec-method", "execve  vfork", "--msg", `a "b" c`, "--x y", ""}))
+		})
+
+		It("Should only honour the escapes of sh within double quotes", func() {
+			for line, expected := range map[string][]string{
+				`"\a"`:       {`\a`},
+				`"\\"`:       {`\`},
+				`"\$HOME"`:   {`$HOME`},
+				"\"\\`\"":    {"`"},
+				`"a\b\"c"`:   {`a\b"c`},
+				`'\a'`:       {`\a`},
+				`\a`:         {`a`},
+				"a\\\nb":     {"ab"},
+				"\"a\\\nb\"": {"ab"},
+			} {
+				Expect(SplitArgs(line)).Should(Equal(expected), line)
+			}
+		})
+
+		It("Should fail on unterminated quotes", func() {
+			_, err := SplitArgs(`--msg "hello`)
+			Expect(err).Should(HaveOccurred())
+			_, err = SplitArgs(`--msg hello\`)
+			Expect(err).Should(HaveOccurred())
+		})
+	})
+
+	Context("Reading the arguments of a spec", func() {
+		It("Should prefer the deprecated custom string", func() {
+			spec := BaselineSpec{Custom: "--timer 2", CustomArgs: []string{"--timer", "1"}}
+			Expect(spec.Args()).Should(Equal([]string{"--timer", "2"}))
+			spec.Custom = ""
+			Expect(spec.Args()).Should(Equal([]string{"--timer", "1"}))
+		})
+	})
+
+	Context("Converting the custom string in the webhook", func() {
+		It("Should move it into customArgs", func() {
+			b := &Baseline{Spec: BaselineSpec{Custom: `--timer 1 --msg "a b"`}}
+			Expect(convertCustom(b)).Should(Succeed())
+			Expect(b.Spec.Custom).Should(BeEmpty())
+			Expect(b.Spec.CustomArgs).Should(Equal([]string{"--timer", "1", "--msg", "a b"}))
+		})
+
+		It("Should reject an invalid custom string", func() {
+			b := &Baseline{Spec: BaselineSpec{Custom: `--msg 'a b`}}
+			Expect(convertCustom(b)).ShouldNot(Succeed())
+		})
+	})
+})
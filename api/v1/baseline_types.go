@@ -18,7 +18,9 @@ package v1
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!s
@@ -36,29 +38,553 @@ type BaselineSpec struct {
 	//+kubebuilder:validation:Optional
 	//+kubebuilder:validation:Minimum=0
 	// Cpu is the the number of cores
-	Io int32 `json:"io"`
+	Io *int32 `json:"io"`
 	//+kubebuilder:validation:Optional
 	//+kubebuilder:validation:Minimum=0
 	// Sock is the number of workers exercising socket I/O networking
-	Sock int32 `json:"sock"`
+	Sock *int32 `json:"sock"`
 	//+kubebuilder:validation:Optional
-	// Custom is a custom string to pass to stress-ng
+	// Workload is the tool generating the load. Defaults to StressNG
+	Workload WorkloadType `json:"workload"`
+	//+kubebuilder:validation:Optional
+	// Fio is the job of the Fio workload
+	Fio *FioWorkload `json:"fio"`
+	//+kubebuilder:validation:Optional
+	// Iperf3 are the tests of the Iperf3 workload
+	Iperf3 *Iperf3Workload `json:"iperf3"`
+	//+kubebuilder:validation:Optional
+	// HTTP is the traffic of the HTTP workload
+	HTTP *HTTPWorkload `json:"http"`
+	//+kubebuilder:validation:Optional
+	// APIServer is the control plane load of the APIServer workload
+	APIServer *APIServerWorkload `json:"apiServer"`
+	//+kubebuilder:validation:Optional
+	// Churn is the pod churn of the Churn workload
+	Churn *ChurnWorkload `json:"churn"`
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=0
+	// Hdd is the number of workers writing and removing temporary files
+	Hdd int32 `json:"hdd"`
+	//+kubebuilder:validation:Optional
+	// HddBytes is the size of the file written by each hdd worker
+	HddBytes string `json:"hddBytes"`
+	//+kubebuilder:validation:Optional
+	// HddWriteSize is the size of each write of the hdd workers
+	HddWriteSize string `json:"hddWriteSize"`
+	//+kubebuilder:validation:Optional
+	// ScratchVolume is the volume the hdd workers write to. Defaults to an
+	// emptyDir
+	ScratchVolume *ScratchVolume `json:"scratchVolume"`
+	//+kubebuilder:validation:Optional
+	// Hugepages backs the memory stressors with hugepages
+	Hugepages *Hugepages `json:"hugepages"`
+	//+kubebuilder:validation:Optional
+	// NUMA binds the stress-ng memory to NUMA nodes. The image must provide
+	// numactl
+	NUMA *NUMA `json:"numa"`
+	//+kubebuilder:validation:Optional
+	// Custom is a custom string to pass to stress-ng, split like a shell
+	// command line. Deprecated: use CustomArgs instead
 	Custom string `json:"custom"`
 	//+kubebuilder:validation:Optional
-	//+kubebuilder:default:="quay.io/jcastillolema/stressng:0.14.01"
+	// CustomArgs are custom arguments to pass to stress-ng, one per item
+	CustomArgs []string `json:"customArgs"`
+	//+kubebuilder:validation:Optional
+	// Image is the stress-ng image, defaulted from the operator configuration
 	Image string `json:"image"`
 	//+kubebuilder:validation:Optional
-	HostNetwork bool `json:"hostNetwork"`
+	// ImagePullSecrets are the secrets the images of the workload are pulled
+	// with
+	ImagePullSecrets []corev1.LocalObjectReference `json:"imagePullSecrets"`
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Enum=Always;Never;IfNotPresent
+	// ImagePullPolicy is the pull policy of the images of the workload
+	ImagePullPolicy corev1.PullPolicy `json:"imagePullPolicy"`
+	//+kubebuilder:validation:Optional
+	// SockInterface is the network interface used by the sock workers
+	SockInterface string `json:"sockInterface"`
+	//+kubebuilder:validation:Optional
+	// Resources are the compute resources of the stress-ng container.
+	// If not set, requests are derived from the number of workers
+	Resources corev1.ResourceRequirements `json:"resources"`
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Type=object
+	//+kubebuilder:pruning:PreserveUnknownFields
+	// PodTemplate is a partial pod template strategic-merged over the stress
+	// pods, i.e. to add annotations, labels, env vars, volumes or image pull
+	// secrets
+	PodTemplate *runtime.RawExtension `json:"podTemplate"`
+	//+kubebuilder:validation:Optional
+	HostNetwork *bool `json:"hostNetwork"`
 	//+kubebuilder:validation:Optional
 	NodeSelector map[string]string `json:"nodeSelector"`
 	//+kubebuilder:validation:Optional
 	Tolerations []corev1.Toleration `json:"tolerations"`
+	//+kubebuilder:validation:Optional
+	// AllowControlPlane allows the workload on the control plane nodes,
+	// which are otherwise excluded even if tolerated
+	AllowControlPlane bool `json:"allowControlPlane"`
+	//+kubebuilder:validation:Optional
+	// ControlPlane caps the workers run on the control plane nodes
+	ControlPlane *ControlPlaneLimits `json:"controlPlane"`
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Pattern=`^[0-9]+(-[0-9]+)?(,[0-9]+(-[0-9]+)?)*$`
+	// CPUSet is the list of cpus the stress-ng workers are pinned to with
+	// --taskset, i.e. 2-5,8
+	CPUSet string `json:"cpuSet"`
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=1
+	// GuaranteedCPUs requests this many exclusive cpus, with the limits equal
+	// to the requests so the pods get the Guaranteed QoS class and the static
+	// CPU manager of the kubelet pins them
+	GuaranteedCPUs *int32 `json:"guaranteedCpus"`
+	//+kubebuilder:validation:Optional
+	// Suspend stops the stress workload without deleting the Baseline. It is
+	// the schedule.suspend field of v2
+	Suspend bool `json:"suspend"`
+	//+kubebuilder:validation:Optional
+	// CoolDown is how long a node must stay healthy before the load removed
+	// from it resumes. Defaults to 5m
+	CoolDown *metav1.Duration `json:"coolDown"`
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=0
+	// TerminationGracePeriodSeconds is how long the stress pods have to print
+	// their final metrics once terminated. Defaults to 30
+	TerminationGracePeriodSeconds *int64 `json:"terminationGracePeriodSeconds"`
+	//+kubebuilder:validation:Optional
+	// DisruptionBudget generates a PodDisruptionBudget for the stress pods
+	DisruptionBudget *DisruptionBudget `json:"disruptionBudget"`
+	//+kubebuilder:validation:Optional
+	// SecurityMode is how the security context of the stress containers is
+	// computed. Defaults to Manual
+	SecurityMode SecurityMode `json:"securityMode"`
+	//+kubebuilder:validation:Optional
+	// SecurityContext is the security context of the stress containers. In
+	// the Auto security mode, its fields override the computed ones
+	SecurityContext *corev1.SecurityContext `json:"securityContext"`
+	//+kubebuilder:validation:Optional
+	// ProfileRef references the BaselineProfile the unset fields are taken from
+	ProfileRef *ProfileReference `json:"profileRef"`
+}
+
+// SecurityMode is how the security context of the stress containers is computed
+//+kubebuilder:validation:Enum=Manual;Auto
+type SecurityMode string
+
+const (
+	// SecurityManual only applies the security context of the spec
+	SecurityManual SecurityMode = "Manual"
+	// SecurityAuto adds the minimal capabilities the stressors need, or
+	// applies the restricted Pod Security defaults when they need none
+	SecurityAuto SecurityMode = "Auto"
+)
+
+// ProfileReference references a cluster-scoped BaselineProfile
+type ProfileReference struct {
+	// Name is the name of the BaselineProfile
+	Name string `json:"name"`
+}
+
+// WorkloadType is the tool generating the load
+//+kubebuilder:validation:Enum=StressNG;Fio;Iperf3;HTTP;APIServer;Churn
+type WorkloadType string
+
+const (
+	// WorkloadStressNG runs the stress-ng workers of the spec
+	WorkloadStressNG WorkloadType = "StressNG"
+	// WorkloadFio runs an fio job against the scratch volume
+	WorkloadFio WorkloadType = "Fio"
+	// WorkloadIperf3 runs iperf3 tests between the nodes
+	WorkloadIperf3 WorkloadType = "Iperf3"
+	// WorkloadHTTP sends HTTP requests to echo servers behind a Service
+	WorkloadHTTP WorkloadType = "HTTP"
+	// WorkloadAPIServer creates, updates, lists and deletes objects in a
+	// sandbox namespace
+	WorkloadAPIServer WorkloadType = "APIServer"
+	// WorkloadChurn continuously creates and deletes lightweight pods
+	WorkloadChurn WorkloadType = "Churn"
+)
+
+// FioWorkload defines the fio job run continuously against the scratch volume
+type FioWorkload struct {
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Enum=read;write;randread;randwrite;rw;randrw
+	// ReadWrite is the I/O pattern. Defaults to randrw
+	ReadWrite string `json:"readWrite"`
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=0
+	//+kubebuilder:validation:Maximum=100
+	// RwMixRead is the percentage of reads of a mixed pattern. Defaults to 50
+	RwMixRead *int32 `json:"rwMixRead"`
+	//+kubebuilder:validation:Optional
+	// BlockSize is the size of each I/O. Defaults to 4k
+	BlockSize string `json:"blockSize"`
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=1
+	// IoDepth is the number of I/Os kept in flight. Defaults to 16
+	IoDepth int32 `json:"ioDepth"`
+	//+kubebuilder:validation:Optional
+	// Size is the size of the file each job writes to. Defaults to 1G
+	Size string `json:"size"`
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=1
+	// NumJobs is the number of jobs running the pattern. Defaults to 1
+	NumJobs int32 `json:"numJobs"`
+	//+kubebuilder:validation:Optional
+	// Runtime is how long each run lasts before its results are reported.
+	// Defaults to 60s
+	Runtime *metav1.Duration `json:"runtime"`
+}
+
+// Iperf3Topology is how the nodes are paired by the Iperf3 workload
+//+kubebuilder:validation:Enum=Pairwise;Ring;AllToAll
+type Iperf3Topology string
+
+const (
+	// TopologyPairwise pairs the nodes two by two
+	TopologyPairwise Iperf3Topology = "Pairwise"
+	// TopologyRing sends from each node to the next one
+	TopologyRing Iperf3Topology = "Ring"
+	// TopologyAllToAll sends from each node to every other node
+	TopologyAllToAll Iperf3Topology = "AllToAll"
+)
+
+// Iperf3Workload defines the iperf3 tests run continuously between nodes
+type Iperf3Workload struct {
+	//+kubebuilder:validation:Optional
+	// Topology is how the nodes are paired. Defaults to Ring
+	Topology Iperf3Topology `json:"topology"`
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Enum=TCP;UDP
+	// Protocol is the transport protocol. Defaults to TCP
+	Protocol string `json:"protocol"`
+	//+kubebuilder:validation:Optional
+	// Bandwidth is the target bitrate of each stream, i.e. 1G. Defaults to
+	// unlimited for TCP and 1M for UDP
+	Bandwidth string `json:"bandwidth"`
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=1
+	// Parallel is the number of parallel streams. Defaults to 1
+	Parallel int32 `json:"parallel"`
+	//+kubebuilder:validation:Optional
+	// Duration is how long each test lasts before its results are reported.
+	// Defaults to 60s
+	Duration *metav1.Duration `json:"duration"`
+}
+
+// HTTPWorkload defines the steady HTTP traffic sent to the echo servers
+type HTTPWorkload struct {
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=1
+	// Servers is the number of echo server replicas behind the Service.
+	// Defaults to 1
+	Servers int32 `json:"servers"`
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=1
+	// Clients is the number of load generator replicas. Defaults to 1
+	Clients int32 `json:"clients"`
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=1
+	// RPS is the number of requests per second sent by each load generator.
+	// Defaults to 100
+	RPS int32 `json:"rps"`
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=0
+	// PayloadSize is the size in bytes of the body of each request, echoed
+	// back by the servers. Defaults to 1024
+	PayloadSize *int32 `json:"payloadSize"`
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=1
+	// Concurrency is the number of requests each load generator keeps in
+	// flight at most. Defaults to 10
+	Concurrency int32 `json:"concurrency"`
+}
+
+// APIServerKind is a kind of object the APIServer workload churns
+//+kubebuilder:validation:Enum=ConfigMaps;Secrets;Pods
+type APIServerKind string
+
+// APIServerWorkload defines the control plane load generated in a sandbox
+// namespace
+type APIServerWorkload struct {
+	//+kubebuilder:validation:Optional
+	// Namespace is the sandbox namespace created for the objects. Defaults to
+	// baseline-<namespace>-<name>
+	Namespace string `json:"namespace"`
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=1
+	// QPS is the number of requests per second sent by each load generator.
+	// Defaults to 10
+	QPS int32 `json:"qps"`
+	//+kubebuilder:validation:Optional
+	// Kinds are the kinds of objects created, updated, listed and deleted in
+	// turn. Pods are created unschedulable. Defaults to ConfigMaps
+	Kinds []APIServerKind `json:"kinds"`
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=1
+	// Objects is the number of objects of each kind kept by each load
+	// generator. Defaults to 100
+	Objects int32 `json:"objects"`
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=1
+	// Clients is the number of load generator replicas. Defaults to 1
+	Clients int32 `json:"clients"`
+}
+
+// ChurnWorkload defines the pods the operator continuously creates and deletes
+type ChurnWorkload struct {
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=1
+	// PodsPerMinute is the rate the pods are created at. Defaults to 60
+	PodsPerMinute int32 `json:"podsPerMinute"`
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=1
+	// MaxConcurrency is the maximum number of churn pods existing at once.
+	// Defaults to 10
+	MaxConcurrency int32 `json:"maxConcurrency"`
+	//+kubebuilder:validation:Optional
+	// Lifetime is how long each pod runs before being deleted. Defaults to 10s
+	Lifetime *metav1.Duration `json:"lifetime"`
+}
+
+// ScratchVolumeType is the kind of volume the hdd workers write to
+//+kubebuilder:validation:Enum=EmptyDir;HostPath;Ephemeral
+type ScratchVolumeType string
+
+const (
+	// ScratchEmptyDir writes to an emptyDir on the node local disk
+	ScratchEmptyDir ScratchVolumeType = "EmptyDir"
+	// ScratchHostPath writes to a directory of the node
+	ScratchHostPath ScratchVolumeType = "HostPath"
+	// ScratchEphemeral writes to a generic ephemeral volume provisioned
+	// from a StorageClass
+	ScratchEphemeral ScratchVolumeType = "Ephemeral"
+)
+
+// ScratchVolume defines the volume the hdd workers write to
+type ScratchVolume struct {
+	//+kubebuilder:validation:Optional
+	// Type is the kind of volume. Defaults to EmptyDir
+	Type ScratchVolumeType `json:"type"`
+	//+kubebuilder:validation:Optional
+	// Size is the size limit of an emptyDir, or the requested storage of an
+	// ephemeral volume, which requires it
+	Size *resource.Quantity `json:"size"`
+	//+kubebuilder:validation:Optional
+	// Path is the directory of the node used by a HostPath volume
+	Path string `json:"path"`
+	//+kubebuilder:validation:Optional
+	// StorageClassName is the StorageClass of an ephemeral volume. Defaults
+	// to the default StorageClass of the cluster
+	StorageClassName *string `json:"storageClassName"`
+}
+
+// HugepageSize is the size of the hugepages backing the memory stressors
+//+kubebuilder:validation:Enum="2Mi";"1Gi"
+type HugepageSize string
+
+const (
+	// Hugepages2Mi are the default hugepages of x86 nodes
+	Hugepages2Mi HugepageSize = "2Mi"
+	// Hugepages1Gi are the gigantic hugepages
+	Hugepages1Gi HugepageSize = "1Gi"
+)
+
+// Hugepages defines the hugepages the memory stressors map
+type Hugepages struct {
+	//+kubebuilder:validation:Optional
+	// Size is the size of the hugepages. Defaults to 2Mi
+	Size HugepageSize `json:"size"`
+	// Amount is the hugepage memory of each stress pod, a multiple of the
+	// size
+	Amount resource.Quantity `json:"amount"`
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=0
+	// Workers is the number of mmaphuge workers. Defaults to 1
+	Workers *int32 `json:"workers"`
+}
+
+// NUMAPolicy is the memory policy of the stress-ng processes
+//+kubebuilder:validation:Enum=Bind;Preferred;Interleave
+type NUMAPolicy string
+
+const (
+	// NUMABind allocates the memory and runs the workers only on the nodes
+	NUMABind NUMAPolicy = "Bind"
+	// NUMAPreferred allocates the memory on the node, falling back to the
+	// others when it is full
+	NUMAPreferred NUMAPolicy = "Preferred"
+	// NUMAInterleave interleaves the memory over the nodes
+	NUMAInterleave NUMAPolicy = "Interleave"
+)
+
+// NUMA defines the NUMA nodes the stress-ng memory is allocated from
+type NUMA struct {
+	//+kubebuilder:validation:Optional
+	// Policy is the memory policy. Defaults to Bind
+	Policy NUMAPolicy `json:"policy"`
+	//+kubebuilder:validation:Pattern=`^[0-9]+(-[0-9]+)?(,[0-9]+(-[0-9]+)?)*$`
+	// Nodes are the NUMA nodes, i.e. 0 or 0-1,3. The Preferred policy takes
+	// a single node
+	Nodes string `json:"nodes"`
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=0
+	// Workers is the number of numa workers migrating pages between the
+	// nodes
+	Workers int32 `json:"workers"`
+}
+
+// DisruptionBudget configures the PodDisruptionBudget of the stress pods
+type DisruptionBudget struct {
+	//+kubebuilder:validation:Minimum=0
+	// MinAvailable is the number of stress pods that must stay available
+	// during voluntary disruptions, i.e. drains. Percentages are not
+	// supported for daemonset pods
+	MinAvailable int32 `json:"minAvailable"`
+}
+
+// ControlPlaneLimits caps the workers run on the control plane nodes, so
+// etcd and the apiserver keep headroom
+type ControlPlaneLimits struct {
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=1
+	// Cpu is the maximum number of cpu workers
+	Cpu *int32 `json:"cpu"`
+	//+kubebuilder:validation:Optional
+	// Memory is the maximum size of the virtual memory
+	Memory string `json:"mem"`
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=0
+	// Io is the maximum number of io workers
+	Io *int32 `json:"io"`
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=0
+	// Sock is the maximum number of sock workers
+	Sock *int32 `json:"sock"`
 }
 
+const (
+	// ConditionHalted is true while the cluster policy halts every Baseline
+	ConditionHalted = "Halted"
+	// ConditionDegraded is true while the Baseline can not run as specified
+	ConditionDegraded = "Degraded"
+)
+
 // BaselineStatus defines the observed state of Baseline
 type BaselineStatus struct {
 	Command string `json:"command"`
 	Custom  string `json:"custom"`
+	//+kubebuilder:validation:Optional
+	// ControlPlaneCommand is the capped command run on the control plane nodes
+	ControlPlaneCommand string `json:"controlPlaneCommand,omitempty"`
+	//+kubebuilder:validation:Optional
+	// StartTime is when the stress workload was first started
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	//+kubebuilder:validation:Optional
+	// Conditions are the Halted and Degraded conditions of the Baseline
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	//+kubebuilder:validation:Optional
+	// AbortedNodes are the unhealthy nodes the load was removed from
+	AbortedNodes []NodeAbort `json:"abortedNodes,omitempty"`
+	//+kubebuilder:validation:Optional
+	// FioResults are the results of the last fio run on each node
+	FioResults []FioResult `json:"fioResults,omitempty"`
+	//+kubebuilder:validation:Optional
+	// Iperf3Results are the results of the last iperf3 test between each pair
+	// of nodes
+	Iperf3Results []Iperf3Result `json:"iperf3Results,omitempty"`
+	//+kubebuilder:validation:Optional
+	// Churn are the statistics of the pods created by the Churn workload
+	Churn *ChurnStatus `json:"churn,omitempty"`
+	//+kubebuilder:validation:Optional
+	// PinnedCPUs are the cpus the stress pods run on, on each node, when
+	// they are pinned
+	PinnedCPUs []PinnedCPUs `json:"pinnedCpus,omitempty"`
+}
+
+// ChurnStatus are the statistics of the pods created by the Churn workload
+type ChurnStatus struct {
+	// PodsCreated is the number of pods created
+	PodsCreated int64 `json:"podsCreated"`
+	// PodsDeleted is the number of pods deleted once running
+	PodsDeleted int64 `json:"podsDeleted"`
+	// PodsFailed is the number of pods that failed or did not run in time
+	PodsFailed int64 `json:"podsFailed"`
+	//+kubebuilder:validation:Optional
+	// Samples is the number of recent pods the latencies are computed from
+	Samples int32 `json:"samples"`
+	//+kubebuilder:validation:Optional
+	// LatencyP50 is the median of the creation-to-running latency
+	LatencyP50 metav1.Duration `json:"latencyP50"`
+	//+kubebuilder:validation:Optional
+	// LatencyP90 is the 90th percentile of the creation-to-running latency
+	LatencyP90 metav1.Duration `json:"latencyP90"`
+	//+kubebuilder:validation:Optional
+	// LatencyP99 is the 99th percentile of the creation-to-running latency
+	LatencyP99 metav1.Duration `json:"latencyP99"`
+}
+
+// PinnedCPUs are the cpus a stress pod runs on
+type PinnedCPUs struct {
+	// Node is the name of the node
+	Node string `json:"node"`
+	// Pod is the name of the stress pod
+	Pod string `json:"pod"`
+	// CPUs is the list of cpus the pod is allowed to run on, i.e. 2-5,8
+	CPUs string `json:"cpus"`
+}
+
+// FioResult are the results of the last fio run on a node
+type FioResult struct {
+	// Node is the name of the node
+	Node string `json:"node"`
+	// ReadIOPS are the read operations per second
+	ReadIOPS int64 `json:"readIOPS"`
+	// WriteIOPS are the write operations per second
+	WriteIOPS int64 `json:"writeIOPS"`
+	//+kubebuilder:validation:Optional
+	// ReadLatency is the mean latency of the reads
+	ReadLatency metav1.Duration `json:"readLatency"`
+	//+kubebuilder:validation:Optional
+	// WriteLatency is the mean latency of the writes
+	WriteLatency metav1.Duration `json:"writeLatency"`
+	// Time is when the run finished
+	Time metav1.Time `json:"time"`
+}
+
+// Iperf3Result are the results of the last iperf3 test between two nodes
+type Iperf3Result struct {
+	// Source is the node running the client
+	Source string `json:"source"`
+	// Destination is the node running the server
+	Destination string `json:"destination"`
+	// BitsPerSecond is the received bitrate
+	BitsPerSecond int64 `json:"bitsPerSecond"`
+	//+kubebuilder:validation:Optional
+	// Retransmits are the TCP retransmits
+	Retransmits int64 `json:"retransmits"`
+	//+kubebuilder:validation:Optional
+	// LostPackets are the lost UDP packets
+	LostPackets int64 `json:"lostPackets"`
+	//+kubebuilder:validation:Optional
+	// Jitter is the UDP jitter
+	Jitter metav1.Duration `json:"jitter"`
+	// Time is when the test started
+	Time metav1.Time `json:"time"`
+}
+
+// NodeAbort records why the load was removed from a node
+type NodeAbort struct {
+	// Node is the name of the node
+	Node string `json:"node"`
+	// Reason is the unhealthy node condition, i.e. NotReady or MemoryPressure
+	Reason string `json:"reason"`
+	// AbortTime is when the load was removed from the node
+	AbortTime metav1.Time `json:"abortTime"`
+	//+kubebuilder:validation:Optional
+	// HealthyTime is when the node was first seen healthy again, starting
+	// the cool-down
+	HealthyTime *metav1.Time `json:"healthyTime,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -66,6 +592,7 @@ type BaselineStatus struct {
 //+kubebuilder:printcolumn:name="Command",type=string,JSONPath=`.status.command`
 //+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 //+kubebuilder:subresource:status
+//+kubebuilder:storageversion
 // Baseline is the Schema for the baselines API
 type Baseline struct {
 	metav1.TypeMeta   `json:",inline"`
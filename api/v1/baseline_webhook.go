@@ -0,0 +1,341 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const (
+	// DefaultImage is the stress-ng image used when neither the Baseline nor
+	// the operator configuration set one
+	DefaultImage = "quay.io/jcastillolema/stressng:0.14.01"
+	// DefaultFioImage is the image of the Fio workload, built from
+	// images/fio
+	DefaultFioImage = "quay.io/jcastillolema/fio:0.1"
+	// DefaultIperf3Image is the image of the Iperf3 workload, built from
+	// images/iperf3
+	DefaultIperf3Image = "quay.io/jcastillolema/iperf3:0.1"
+	// DefaultToolsImage is the image of the HTTP and APIServer workloads, the
+	// operator image shipping the httpbench and apiload binaries
+	DefaultToolsImage = "quay.io/jcastillolema/baseline-operator:0.1"
+	// DefaultChurnImage is the image of the pods of the Churn workload
+	DefaultChurnImage = "registry.k8s.io/pause:3.7"
+	// DefaultSockInterface is the network interface used by the sock workers
+	DefaultSockInterface = "eth0"
+
+	// DerivedResourcesAnnotation records the requests derived from the
+	// workers, so they can be re-derived while the user does not override them
+	DerivedResourcesAnnotation = "perf.baseline.io/derived-resources"
+)
+
+// log is for logging in this package.
+var baselinelog = logf.Log.WithName("baseline-resource")
+
+// previousDefaultImages are the default images of the previous versions of
+// the operator, still replaced by the current ones
+var previousDefaultImages = []string{
+	"quay.io/cloud-bulldozer/fio:latest",
+	"docker.io/networkstatic/iperf3:latest",
+}
+
+// BaselineDefaulter fills the unset fields of a Baseline, so the stored
+// object reflects what actually runs
+//+kubebuilder:object:generate=false
+type BaselineDefaulter struct {
+	// Image is the operator-wide default stress-ng image
+	Image string
+	// ToolsImage is the image of the HTTP and APIServer workloads
+	ToolsImage string
+	// Registry replaces the registry of the default images, i.e. a mirror
+	// of the public registries
+	Registry string
+
+	// mu guards the images, reloaded with the operator configuration
+	mu sync.RWMutex
+	// replaced are the default images before a reload, still followed
+	replaced []string
+}
+
+// SetImages replaces the default images and the registry they are pulled
+// from, i.e. when the operator configuration is reloaded
+func (d *BaselineDefaulter) SetImages(image, toolsImage, registry string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, i := range []string{d.image(), d.mirror(d.image()), d.toolsImage(), d.mirror(d.toolsImage())} {
+		if !contains(d.replaced, i) {
+			d.replaced = append(d.replaced, i)
+		}
+	}
+	d.Image = image
+	d.ToolsImage = toolsImage
+	d.Registry = registry
+}
+
+// SetupWebhookWithManager registers the Baseline webhooks with the manager
+func (r *Baseline) SetupWebhookWithManager(mgr ctrl.Manager, d *BaselineDefaulter) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		WithDefaulter(d).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-perf-baseline-io-v1-baseline,mutating=true,failurePolicy=fail,sideEffects=None,groups=perf.baseline.io,resources=baselines,verbs=create;update,versions=v1,name=mbaseline.kb.io,admissionReviewVersions=v1
+
+var _ admission.CustomDefaulter = &BaselineDefaulter{}
+
+// Default implements admission.CustomDefaulter
+func (d *BaselineDefaulter) Default(ctx context.Context, obj runtime.Object) error {
+	b, ok := obj.(*Baseline)
+	if !ok {
+		return fmt.Errorf("expected a Baseline but got a %T", obj)
+	}
+	baselinelog.Info("default", "name", b.Name)
+	if err := convertCustom(b); err != nil {
+		return err
+	}
+	if b.Spec.ProfileRef != nil {
+		// The fields coming from the profile are only known by the
+		// reconciler, which defaults the Baseline once merged
+		setStandardLabels(b)
+		return nil
+	}
+	d.SetDefaults(b)
+	return nil
+}
+
+// convertCustom moves the deprecated Custom string into CustomArgs
+func convertCustom(b *Baseline) error {
+	if b.Spec.Custom == "" {
+		return nil
+	}
+	args, err := SplitArgs(b.Spec.Custom)
+	if err != nil {
+		return fmt.Errorf("invalid custom: %w", err)
+	}
+	b.Spec.CustomArgs = args
+	b.Spec.Custom = ""
+	return nil
+}
+
+// SetDefaults fills the workload, the image, the sock interface, the
+// resources and the standard labels of the given Baseline
+func (d *BaselineDefaulter) SetDefaults(b *Baseline) {
+	if d != nil {
+		d.mu.RLock()
+		defer d.mu.RUnlock()
+	}
+	if b.Spec.Workload == "" {
+		b.Spec.Workload = WorkloadStressNG
+	}
+	// The image follows the workload and the registry while it is a
+	// default one
+	image := d.mirror(d.imageFor(b.Spec.Workload))
+	if b.Spec.Image == "" || (b.Spec.Image != image && d.isDefaultImage(b.Spec.Image)) {
+		b.Spec.Image = image
+	}
+	if b.Spec.SockInterface == "" {
+		b.Spec.SockInterface = DefaultSockInterface
+	}
+	defaultResources(b)
+	setStandardLabels(b)
+}
+
+// setStandardLabels adds the recommended Kubernetes labels the Baseline lacks
+func setStandardLabels(b *Baseline) {
+	if b.Labels == nil {
+		b.Labels = map[string]string{}
+	}
+	for k, v := range standardLabels(b.Name) {
+		if _, ok := b.Labels[k]; !ok {
+			b.Labels[k] = v
+		}
+	}
+}
+
+// image returns the configured default image
+func (d *BaselineDefaulter) image() string {
+	if d == nil || d.Image == "" {
+		return DefaultImage
+	}
+	return d.Image
+}
+
+// imageFor returns the default image of the workload
+func (d *BaselineDefaulter) imageFor(workload WorkloadType) string {
+	switch workload {
+	case WorkloadFio:
+		return DefaultFioImage
+	case WorkloadIperf3:
+		return DefaultIperf3Image
+	case WorkloadHTTP, WorkloadAPIServer:
+		return d.toolsImage()
+	case WorkloadChurn:
+		return DefaultChurnImage
+	}
+	return d.image()
+}
+
+// toolsImage returns the configured image of the HTTP and APIServer workloads
+func (d *BaselineDefaulter) toolsImage() string {
+	if d == nil || d.ToolsImage == "" {
+		return DefaultToolsImage
+	}
+	return d.ToolsImage
+}
+
+// isDefaultImage returns if the image is the default of any workload, from
+// its original registry or from the mirror
+func (d *BaselineDefaulter) isDefaultImage(image string) bool {
+	defaults := []string{d.image(), DefaultImage, DefaultFioImage, DefaultIperf3Image, d.toolsImage(), DefaultToolsImage, DefaultChurnImage}
+	for _, i := range append(defaults, previousDefaultImages...) {
+		if image == i || image == d.mirror(i) {
+			return true
+		}
+	}
+	return d != nil && contains(d.replaced, image)
+}
+
+// mirror returns the image pulled from the configured registry instead of
+// its own, keeping its repository path
+func (d *BaselineDefaulter) mirror(image string) string {
+	if d == nil || d.Registry == "" {
+		return image
+	}
+	repository := image
+	if i := strings.Index(image, "/"); i >= 0 {
+		// The first component is a registry if it is a host name
+		if host := image[:i]; strings.ContainsAny(host, ".:") || host == "localhost" {
+			repository = image[i+1:]
+		}
+	}
+	return strings.TrimSuffix(d.Registry, "/") + "/" + repository
+}
+
+// standardLabels returns the recommended Kubernetes labels for a Baseline
+func standardLabels(name string) map[string]string {
+	ls := map[string]string{
+		"app.kubernetes.io/name":       "baseline",
+		"app.kubernetes.io/managed-by": "baseline-operator",
+	}
+	if name != "" {
+		ls["app.kubernetes.io/instance"] = name
+	}
+	return ls
+}
+
+// defaultResources derives the container requests from the workers, unless
+// the user set their own resources
+func defaultResources(b *Baseline) {
+	resources := &b.Spec.Resources
+	previous, wasDerived := b.Annotations[DerivedResourcesAnnotation]
+	if len(resources.Limits) != 0 ||
+		(len(resources.Requests) != 0 && (!wasDerived || previous != formatResourceList(resources.Requests))) {
+		delete(b.Annotations, DerivedResourcesAnnotation)
+		return
+	}
+
+	requests := DeriveRequests(&b.Spec)
+	if len(requests) == 0 {
+		resources.Requests = nil
+		delete(b.Annotations, DerivedResourcesAnnotation)
+		return
+	}
+	resources.Requests = requests
+	if b.Annotations == nil {
+		b.Annotations = map[string]string{}
+	}
+	b.Annotations[DerivedResourcesAnnotation] = formatResourceList(requests)
+}
+
+// DeriveRequests returns the requests needed by the workers of the spec:
+// a core per cpu worker, a tenth of a core per io, sock and hdd worker and
+// the virtual memory size
+func DeriveRequests(spec *BaselineSpec) corev1.ResourceList {
+	requests := corev1.ResourceList{}
+	var milliCpu int64
+	if spec.Cpu != nil {
+		// --cpu 0 means all the online cpus, which can not be known here
+		milliCpu += 1000 * int64(*spec.Cpu)
+	}
+	milliCpu += 100 * int64(spec.IoWorkers()+spec.SockWorkers()+spec.Hdd)
+	if milliCpu > 0 {
+		requests[corev1.ResourceCPU] = *resource.NewMilliQuantity(milliCpu, resource.DecimalSI)
+	}
+	if mem, ok := ParseSize(spec.Memory); ok {
+		requests[corev1.ResourceMemory] = *resource.NewQuantity(mem, resource.BinarySI)
+	}
+	return requests
+}
+
+// ParseSize converts a stress-ng size (i.e. 512m, 1G) into bytes. Sizes
+// relative to the available memory (i.e. 50%) are not converted
+func ParseSize(size string) (int64, bool) {
+	size = strings.TrimSpace(size)
+	if size == "" || strings.HasSuffix(size, "%") {
+		return 0, false
+	}
+	multipliers := map[byte]int64{
+		'b': 1,
+		'k': 1 << 10,
+		'm': 1 << 20,
+		'g': 1 << 30,
+		't': 1 << 40,
+	}
+	multiplier := int64(1)
+	if m, ok := multipliers[strings.ToLower(size)[len(size)-1]]; ok {
+		multiplier = m
+		size = size[:len(size)-1]
+	}
+	n, err := strconv.ParseInt(size, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+	return n * multiplier, true
+}
+
+// formatResourceList renders a resource list as sorted name=quantity pairs
+func formatResourceList(l corev1.ResourceList) string {
+	items := make([]string, 0, len(l))
+	for name, q := range l {
+		items = append(items, fmt.Sprintf("%s=%s", name, q.String()))
+	}
+	sort.Strings(items)
+	return strings.Join(items, ",")
+}
+
+// contains returns if the list contains the item
+func contains(list []string, item string) bool {
+	for _, i := range list {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,174 @@
+package v1
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Baseline defaulting webhook", func() {
+
+	newBaseline := func() *Baseline {
+		cpu, io, sock := int32(2), int32(1), int32(1)
+		return &Baseline{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-baseline",
+				Namespace: "default",
+			},
+			Spec: BaselineSpec{
+				Cpu:    &cpu,
+				Memory: "1G",
+				Io:     &io,
+				Sock:   &sock,
+			},
+		}
+	}
+
+	Context("Defaulting a Baseline with only its workers set", func() {
+		It("Should fill the image, the interface, the resources and the labels", func() {
+			b := newBaseline()
+			d := &BaselineDefaulter{Image: "registry.local/stressng:latest"}
+			Expect(d.Default(context.Background(), b)).Should(Succeed())
+
+			Expect(b.Spec.Image).Should(Equal("registry.local/stressng:latest"))
+			Expect(b.Spec.SockInterface).Should(Equal(DefaultSockInterface))
+			Expect(b.Spec.Resources.Requests.Cpu().Equal(resource.MustParse("2200m"))).Should(BeTrue())
+			Expect(b.Spec.Resources.Requests.Memory().Equal(resource.MustParse("1Gi"))).Should(BeTrue())
+			Expect(b.Annotations).Should(HaveKeyWithValue(DerivedResourcesAnnotation, "cpu=2200m,memory=1Gi"))
+			Expect(b.Labels).Should(HaveKeyWithValue("app.kubernetes.io/instance", "test-baseline"))
+			Expect(b.Labels).Should(HaveKeyWithValue("app.kubernetes.io/managed-by", "baseline-operator"))
+		})
+
+		It("Should follow the workload with the default image", func() {
+			b := newBaseline()
+			d := &BaselineDefaulter{}
+			d.SetDefaults(b)
+			Expect(b.Spec.Workload).Should(Equal(WorkloadStressNG))
+			Expect(b.Spec.Image).Should(Equal(DefaultImage))
+
+			b.Spec.Workload = WorkloadFio
+			d.SetDefaults(b)
+			Expect(b.Spec.Image).Should(Equal(DefaultFioImage))
+		})
+
+		It("Should replace the default images of the previous versions", func() {
+			b := newBaseline()
+			b.Spec.Workload = WorkloadFio
+			b.Spec.Image = "quay.io/cloud-bulldozer/fio:latest"
+			(&BaselineDefaulter{}).SetDefaults(b)
+			Expect(b.Spec.Image).Should(Equal(DefaultFioImage))
+
+			b.Spec.Workload = WorkloadIperf3
+			b.Spec.Image = "docker.io/networkstatic/iperf3:latest"
+			(&BaselineDefaulter{}).SetDefaults(b)
+			Expect(b.Spec.Image).Should(Equal(DefaultIperf3Image))
+		})
+
+		It("Should fall back to the built-in image", func() {
+			b := newBaseline()
+			var d *BaselineDefaulter
+			d.SetDefaults(b)
+			Expect(b.Spec.Image).Should(Equal(DefaultImage))
+		})
+	})
+
+	Context("Defaulting with a registry mirror", func() {
+		It("Should pull the default images from the mirror", func() {
+			b := newBaseline()
+			d := &BaselineDefaulter{Registry: "mirror.local:5000/"}
+			d.SetDefaults(b)
+			Expect(b.Spec.Image).Should(Equal("mirror.local:5000/jcastillolema/stressng:0.14.01"))
+
+			b.Spec.Workload = WorkloadIperf3
+			d.SetDefaults(b)
+			Expect(b.Spec.Image).Should(Equal("mirror.local:5000/jcastillolema/iperf3:0.1"))
+		})
+
+		It("Should rewrite the default images already stored", func() {
+			b := newBaseline()
+			b.Spec.Image = DefaultImage
+			(&BaselineDefaulter{Registry: "mirror.local"}).SetDefaults(b)
+			Expect(b.Spec.Image).Should(Equal("mirror.local/jcastillolema/stressng:0.14.01"))
+		})
+
+		It("Should follow the reloaded images", func() {
+			b := newBaseline()
+			d := &BaselineDefaulter{Image: "registry.local/stressng:1"}
+			d.SetDefaults(b)
+			d.SetImages("registry.local/stressng:2", "", "")
+			d.SetDefaults(b)
+			Expect(b.Spec.Image).Should(Equal("registry.local/stressng:2"))
+		})
+
+		It("Should not mirror custom images", func() {
+			b := newBaseline()
+			b.Spec.Image = "quay.io/cloud-bulldozer/stressng"
+			(&BaselineDefaulter{Registry: "mirror.local"}).SetDefaults(b)
+			Expect(b.Spec.Image).Should(Equal("quay.io/cloud-bulldozer/stressng"))
+		})
+	})
+
+	Context("Defaulting a Baseline with user defined fields", func() {
+		It("Should keep them", func() {
+			b := newBaseline()
+			b.Spec.Image = "quay.io/cloud-bulldozer/stressng"
+			b.Spec.SockInterface = "lo"
+			b.Spec.Resources.Limits = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}
+			b.Labels = map[string]string{"app.kubernetes.io/name": "mine"}
+			(&BaselineDefaulter{}).SetDefaults(b)
+
+			Expect(b.Spec.Image).Should(Equal("quay.io/cloud-bulldozer/stressng"))
+			Expect(b.Spec.SockInterface).Should(Equal("lo"))
+			Expect(b.Spec.Resources.Requests).Should(BeEmpty())
+			Expect(b.Annotations).ShouldNot(HaveKey(DerivedResourcesAnnotation))
+			Expect(b.Labels).Should(HaveKeyWithValue("app.kubernetes.io/name", "mine"))
+		})
+	})
+
+	Context("Updating the workers of a defaulted Baseline", func() {
+		It("Should derive the resources again", func() {
+			b := newBaseline()
+			d := &BaselineDefaulter{}
+			d.SetDefaults(b)
+			*b.Spec.Cpu = 4
+			b.Spec.Memory = "50%"
+			d.SetDefaults(b)
+
+			Expect(b.Spec.Resources.Requests.Cpu().Equal(resource.MustParse("4200m"))).Should(BeTrue())
+			Expect(b.Spec.Resources.Requests).ShouldNot(HaveKey(corev1.ResourceMemory))
+		})
+
+		It("Should not override the resources edited by the user", func() {
+			b := newBaseline()
+			d := &BaselineDefaulter{}
+			d.SetDefaults(b)
+			b.Spec.Resources.Requests[corev1.ResourceCPU] = resource.MustParse("500m")
+			*b.Spec.Cpu = 4
+			d.SetDefaults(b)
+
+			Expect(b.Spec.Resources.Requests.Cpu().Equal(resource.MustParse("500m"))).Should(BeTrue())
+			Expect(b.Annotations).ShouldNot(HaveKey(DerivedResourcesAnnotation))
+		})
+	})
+
+	Context("Parsing stress-ng sizes", func() {
+		It("Should convert absolute sizes into bytes", func() {
+			for size, bytes := range map[string]int64{"512": 512, "4k": 4096, "1G": 1 << 30, "2m": 2 << 20} {
+				n, ok := ParseSize(size)
+				Expect(ok).Should(BeTrue())
+				Expect(n).Should(Equal(bytes))
+			}
+		})
+
+		It("Should not convert relative or invalid sizes", func() {
+			for _, size := range []string{"", "50%", "G", "-1G", "1.5G"} {
+				_, ok := ParseSize(size)
+				Expect(ok).Should(BeFalse())
+			}
+		})
+	})
+})
@@ -0,0 +1,79 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PolicyName is the name of the only BaselinePolicy honored by the operator
+const PolicyName = "cluster"
+
+// BaselinePolicySpec defines the guardrails every Baseline must respect
+type BaselinePolicySpec struct {
+	//+kubebuilder:validation:Optional
+	// Halt stops the workload of every Baseline until it is unset
+	Halt bool `json:"halt"`
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=1
+	// MaxCpuPerNode is the maximum number of cpu workers a Baseline can run on a node
+	MaxCpuPerNode *int32 `json:"maxCpuPerNode"`
+	//+kubebuilder:validation:Optional
+	// MaxMemoryPerNode is the maximum virtual memory a Baseline can use on a node
+	MaxMemoryPerNode *resource.Quantity `json:"maxMemoryPerNode"`
+	//+kubebuilder:validation:Optional
+	// ForbiddenNodeLabels are the labels of the nodes Baselines can not run on
+	ForbiddenNodeLabels []ForbiddenNodeLabel `json:"forbiddenNodeLabels"`
+}
+
+// ForbiddenNodeLabel forbids the nodes having a label
+type ForbiddenNodeLabel struct {
+	// Key is the label key, i.e. node-role.kubernetes.io/control-plane
+	Key string `json:"key"`
+	//+kubebuilder:validation:Optional
+	// AllowedNamespaces are the namespaces whose Baselines are explicitly
+	// allowed on the nodes having the label
+	AllowedNamespaces []string `json:"allowedNamespaces"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:scope=Cluster
+//+kubebuilder:printcolumn:name="Halt",type=boolean,JSONPath=`.spec.halt`
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// BaselinePolicy is the Schema for the baselinepolicies API. Only the policy
+// named cluster is honored
+type BaselinePolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec BaselinePolicySpec `json:"spec"`
+}
+
+//+kubebuilder:object:root=true
+
+// BaselinePolicyList contains a list of BaselinePolicy
+type BaselinePolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []BaselinePolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&BaselinePolicy{}, &BaselinePolicyList{})
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// BaselineProfileSpec defines the stressor and placement settings shared by
+// the Baselines referencing the profile
+type BaselineProfileSpec struct {
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=0
+	// Cpu is the the number of cores
+	Cpu *int32 `json:"cpu"`
+	//+kubebuilder:validation:Optional
+	// Memory is the amount of memory
+	Memory string `json:"mem"`
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=0
+	// Io is the number of workers continuously calling sync
+	Io *int32 `json:"io"`
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=0
+	// Sock is the number of workers exercising socket I/O networking
+	Sock *int32 `json:"sock"`
+	//+kubebuilder:validation:Optional
+	// SockInterface is the network interface used by the sock workers
+	SockInterface string `json:"sockInterface"`
+	//+kubebuilder:validation:Optional
+	// CustomArgs are custom arguments to pass to stress-ng, one per item
+	CustomArgs []string `json:"customArgs"`
+	//+kubebuilder:validation:Optional
+	// Image is the stress-ng image
+	Image string `json:"image"`
+	//+kubebuilder:validation:Optional
+	HostNetwork *bool `json:"hostNetwork"`
+	//+kubebuilder:validation:Optional
+	NodeSelector map[string]string `json:"nodeSelector"`
+	//+kubebuilder:validation:Optional
+	Tolerations []corev1.Toleration `json:"tolerations"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:scope=Cluster
+
+// BaselineProfile is the Schema for the baselineprofiles API
+type BaselineProfile struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec BaselineProfileSpec `json:"spec"`
+}
+
+//+kubebuilder:object:root=true
+
+// BaselineProfileList contains a list of BaselineProfile
+type BaselineProfileList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []BaselineProfile `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&BaselineProfile{}, &BaselineProfileList{})
+}
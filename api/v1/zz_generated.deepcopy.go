@@ -23,16 +23,37 @@ package v1
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *APIServerWorkload) DeepCopyInto(out *APIServerWorkload) {
+	*out = *in
+	if in.Kinds != nil {
+		in, out := &in.Kinds, &out.Kinds
+		*out = make([]APIServerKind, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new APIServerWorkload.
+func (in *APIServerWorkload) DeepCopy() *APIServerWorkload {
+	if in == nil {
+		return nil
+	}
+	out := new(APIServerWorkload)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Baseline) DeepCopyInto(out *Baseline) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Baseline.
@@ -85,6 +106,208 @@ func (in *BaselineList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BaselinePolicy) DeepCopyInto(out *BaselinePolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BaselinePolicy.
+func (in *BaselinePolicy) DeepCopy() *BaselinePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(BaselinePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BaselinePolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BaselinePolicyList) DeepCopyInto(out *BaselinePolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]BaselinePolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BaselinePolicyList.
+func (in *BaselinePolicyList) DeepCopy() *BaselinePolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(BaselinePolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BaselinePolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BaselinePolicySpec) DeepCopyInto(out *BaselinePolicySpec) {
+	*out = *in
+	if in.MaxCpuPerNode != nil {
+		in, out := &in.MaxCpuPerNode, &out.MaxCpuPerNode
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxMemoryPerNode != nil {
+		in, out := &in.MaxMemoryPerNode, &out.MaxMemoryPerNode
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.ForbiddenNodeLabels != nil {
+		in, out := &in.ForbiddenNodeLabels, &out.ForbiddenNodeLabels
+		*out = make([]ForbiddenNodeLabel, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BaselinePolicySpec.
+func (in *BaselinePolicySpec) DeepCopy() *BaselinePolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BaselinePolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BaselineProfile) DeepCopyInto(out *BaselineProfile) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BaselineProfile.
+func (in *BaselineProfile) DeepCopy() *BaselineProfile {
+	if in == nil {
+		return nil
+	}
+	out := new(BaselineProfile)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BaselineProfile) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BaselineProfileList) DeepCopyInto(out *BaselineProfileList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]BaselineProfile, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BaselineProfileList.
+func (in *BaselineProfileList) DeepCopy() *BaselineProfileList {
+	if in == nil {
+		return nil
+	}
+	out := new(BaselineProfileList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BaselineProfileList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BaselineProfileSpec) DeepCopyInto(out *BaselineProfileSpec) {
+	*out = *in
+	if in.Cpu != nil {
+		in, out := &in.Cpu, &out.Cpu
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Io != nil {
+		in, out := &in.Io, &out.Io
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Sock != nil {
+		in, out := &in.Sock, &out.Sock
+		*out = new(int32)
+		**out = **in
+	}
+	if in.CustomArgs != nil {
+		in, out := &in.CustomArgs, &out.CustomArgs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.HostNetwork != nil {
+		in, out := &in.HostNetwork, &out.HostNetwork
+		*out = new(bool)
+		**out = **in
+	}
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]corev1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BaselineProfileSpec.
+func (in *BaselineProfileSpec) DeepCopy() *BaselineProfileSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BaselineProfileSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BaselineSpec) DeepCopyInto(out *BaselineSpec) {
 	*out = *in
@@ -93,6 +316,77 @@ func (in *BaselineSpec) DeepCopyInto(out *BaselineSpec) {
 		*out = new(int32)
 		**out = **in
 	}
+	if in.Io != nil {
+		in, out := &in.Io, &out.Io
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Sock != nil {
+		in, out := &in.Sock, &out.Sock
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Fio != nil {
+		in, out := &in.Fio, &out.Fio
+		*out = new(FioWorkload)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Iperf3 != nil {
+		in, out := &in.Iperf3, &out.Iperf3
+		*out = new(Iperf3Workload)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HTTP != nil {
+		in, out := &in.HTTP, &out.HTTP
+		*out = new(HTTPWorkload)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.APIServer != nil {
+		in, out := &in.APIServer, &out.APIServer
+		*out = new(APIServerWorkload)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Churn != nil {
+		in, out := &in.Churn, &out.Churn
+		*out = new(ChurnWorkload)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ScratchVolume != nil {
+		in, out := &in.ScratchVolume, &out.ScratchVolume
+		*out = new(ScratchVolume)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Hugepages != nil {
+		in, out := &in.Hugepages, &out.Hugepages
+		*out = new(Hugepages)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NUMA != nil {
+		in, out := &in.NUMA, &out.NUMA
+		*out = new(NUMA)
+		**out = **in
+	}
+	if in.CustomArgs != nil {
+		in, out := &in.CustomArgs, &out.CustomArgs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ImagePullSecrets != nil {
+		in, out := &in.ImagePullSecrets, &out.ImagePullSecrets
+		*out = make([]corev1.LocalObjectReference, len(*in))
+		copy(*out, *in)
+	}
+	in.Resources.DeepCopyInto(&out.Resources)
+	if in.PodTemplate != nil {
+		in, out := &in.PodTemplate, &out.PodTemplate
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HostNetwork != nil {
+		in, out := &in.HostNetwork, &out.HostNetwork
+		*out = new(bool)
+		**out = **in
+	}
 	if in.NodeSelector != nil {
 		in, out := &in.NodeSelector, &out.NodeSelector
 		*out = make(map[string]string, len(*in))
@@ -107,6 +401,41 @@ func (in *BaselineSpec) DeepCopyInto(out *BaselineSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.ControlPlane != nil {
+		in, out := &in.ControlPlane, &out.ControlPlane
+		*out = new(ControlPlaneLimits)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.GuaranteedCPUs != nil {
+		in, out := &in.GuaranteedCPUs, &out.GuaranteedCPUs
+		*out = new(int32)
+		**out = **in
+	}
+	if in.CoolDown != nil {
+		in, out := &in.CoolDown, &out.CoolDown
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.TerminationGracePeriodSeconds != nil {
+		in, out := &in.TerminationGracePeriodSeconds, &out.TerminationGracePeriodSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.DisruptionBudget != nil {
+		in, out := &in.DisruptionBudget, &out.DisruptionBudget
+		*out = new(DisruptionBudget)
+		**out = **in
+	}
+	if in.SecurityContext != nil {
+		in, out := &in.SecurityContext, &out.SecurityContext
+		*out = new(corev1.SecurityContext)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ProfileRef != nil {
+		in, out := &in.ProfileRef, &out.ProfileRef
+		*out = new(ProfileReference)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BaselineSpec.
@@ -122,6 +451,48 @@ func (in *BaselineSpec) DeepCopy() *BaselineSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BaselineStatus) DeepCopyInto(out *BaselineStatus) {
 	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.AbortedNodes != nil {
+		in, out := &in.AbortedNodes, &out.AbortedNodes
+		*out = make([]NodeAbort, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.FioResults != nil {
+		in, out := &in.FioResults, &out.FioResults
+		*out = make([]FioResult, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Iperf3Results != nil {
+		in, out := &in.Iperf3Results, &out.Iperf3Results
+		*out = make([]Iperf3Result, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Churn != nil {
+		in, out := &in.Churn, &out.Churn
+		*out = new(ChurnStatus)
+		**out = **in
+	}
+	if in.PinnedCPUs != nil {
+		in, out := &in.PinnedCPUs, &out.PinnedCPUs
+		*out = make([]PinnedCPUs, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BaselineStatus.
@@ -133,3 +504,317 @@ func (in *BaselineStatus) DeepCopy() *BaselineStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChurnStatus) DeepCopyInto(out *ChurnStatus) {
+	*out = *in
+	out.LatencyP50 = in.LatencyP50
+	out.LatencyP90 = in.LatencyP90
+	out.LatencyP99 = in.LatencyP99
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChurnStatus.
+func (in *ChurnStatus) DeepCopy() *ChurnStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ChurnStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChurnWorkload) DeepCopyInto(out *ChurnWorkload) {
+	*out = *in
+	if in.Lifetime != nil {
+		in, out := &in.Lifetime, &out.Lifetime
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChurnWorkload.
+func (in *ChurnWorkload) DeepCopy() *ChurnWorkload {
+	if in == nil {
+		return nil
+	}
+	out := new(ChurnWorkload)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ControlPlaneLimits) DeepCopyInto(out *ControlPlaneLimits) {
+	*out = *in
+	if in.Cpu != nil {
+		in, out := &in.Cpu, &out.Cpu
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Io != nil {
+		in, out := &in.Io, &out.Io
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Sock != nil {
+		in, out := &in.Sock, &out.Sock
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ControlPlaneLimits.
+func (in *ControlPlaneLimits) DeepCopy() *ControlPlaneLimits {
+	if in == nil {
+		return nil
+	}
+	out := new(ControlPlaneLimits)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DisruptionBudget) DeepCopyInto(out *DisruptionBudget) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DisruptionBudget.
+func (in *DisruptionBudget) DeepCopy() *DisruptionBudget {
+	if in == nil {
+		return nil
+	}
+	out := new(DisruptionBudget)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FioResult) DeepCopyInto(out *FioResult) {
+	*out = *in
+	out.ReadLatency = in.ReadLatency
+	out.WriteLatency = in.WriteLatency
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FioResult.
+func (in *FioResult) DeepCopy() *FioResult {
+	if in == nil {
+		return nil
+	}
+	out := new(FioResult)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FioWorkload) DeepCopyInto(out *FioWorkload) {
+	*out = *in
+	if in.RwMixRead != nil {
+		in, out := &in.RwMixRead, &out.RwMixRead
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Runtime != nil {
+		in, out := &in.Runtime, &out.Runtime
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FioWorkload.
+func (in *FioWorkload) DeepCopy() *FioWorkload {
+	if in == nil {
+		return nil
+	}
+	out := new(FioWorkload)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ForbiddenNodeLabel) DeepCopyInto(out *ForbiddenNodeLabel) {
+	*out = *in
+	if in.AllowedNamespaces != nil {
+		in, out := &in.AllowedNamespaces, &out.AllowedNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ForbiddenNodeLabel.
+func (in *ForbiddenNodeLabel) DeepCopy() *ForbiddenNodeLabel {
+	if in == nil {
+		return nil
+	}
+	out := new(ForbiddenNodeLabel)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPWorkload) DeepCopyInto(out *HTTPWorkload) {
+	*out = *in
+	if in.PayloadSize != nil {
+		in, out := &in.PayloadSize, &out.PayloadSize
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPWorkload.
+func (in *HTTPWorkload) DeepCopy() *HTTPWorkload {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPWorkload)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Hugepages) DeepCopyInto(out *Hugepages) {
+	*out = *in
+	out.Amount = in.Amount.DeepCopy()
+	if in.Workers != nil {
+		in, out := &in.Workers, &out.Workers
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Hugepages.
+func (in *Hugepages) DeepCopy() *Hugepages {
+	if in == nil {
+		return nil
+	}
+	out := new(Hugepages)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Iperf3Result) DeepCopyInto(out *Iperf3Result) {
+	*out = *in
+	out.Jitter = in.Jitter
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Iperf3Result.
+func (in *Iperf3Result) DeepCopy() *Iperf3Result {
+	if in == nil {
+		return nil
+	}
+	out := new(Iperf3Result)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Iperf3Workload) DeepCopyInto(out *Iperf3Workload) {
+	*out = *in
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Iperf3Workload.
+func (in *Iperf3Workload) DeepCopy() *Iperf3Workload {
+	if in == nil {
+		return nil
+	}
+	out := new(Iperf3Workload)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NUMA) DeepCopyInto(out *NUMA) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NUMA.
+func (in *NUMA) DeepCopy() *NUMA {
+	if in == nil {
+		return nil
+	}
+	out := new(NUMA)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeAbort) DeepCopyInto(out *NodeAbort) {
+	*out = *in
+	in.AbortTime.DeepCopyInto(&out.AbortTime)
+	if in.HealthyTime != nil {
+		in, out := &in.HealthyTime, &out.HealthyTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeAbort.
+func (in *NodeAbort) DeepCopy() *NodeAbort {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeAbort)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PinnedCPUs) DeepCopyInto(out *PinnedCPUs) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PinnedCPUs.
+func (in *PinnedCPUs) DeepCopy() *PinnedCPUs {
+	if in == nil {
+		return nil
+	}
+	out := new(PinnedCPUs)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProfileReference) DeepCopyInto(out *ProfileReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProfileReference.
+func (in *ProfileReference) DeepCopy() *ProfileReference {
+	if in == nil {
+		return nil
+	}
+	out := new(ProfileReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScratchVolume) DeepCopyInto(out *ScratchVolume) {
+	*out = *in
+	if in.Size != nil {
+		in, out := &in.Size, &out.Size
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.StorageClassName != nil {
+		in, out := &in.StorageClassName, &out.StorageClassName
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScratchVolume.
+func (in *ScratchVolume) DeepCopy() *ScratchVolume {
+	if in == nil {
+		return nil
+	}
+	out := new(ScratchVolume)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,400 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	perfv1 "github.com/josecastillolema/baseline-operator/api/v1"
+)
+
+// CustomAnnotation keeps the deprecated v1 custom string, which has no v2
+// field, until the defaulting webhook converts it into customArgs
+const CustomAnnotation = "perf.baseline.io/v1-custom"
+
+var _ conversion.Convertible = &Baseline{}
+
+// ConvertTo converts this Baseline to the hub (v1) version
+func (src *Baseline) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*perfv1.Baseline)
+
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	dst.Spec.Custom = dst.Annotations[CustomAnnotation]
+	delete(dst.Annotations, CustomAnnotation)
+
+	dst.Spec.Workload = perfv1.WorkloadType(src.Spec.Workload)
+	if fio := src.Spec.Fio.DeepCopy(); fio != nil {
+		dst.Spec.Fio = &perfv1.FioWorkload{
+			ReadWrite: fio.ReadWrite,
+			RwMixRead: fio.RwMixRead,
+			BlockSize: fio.BlockSize,
+			IoDepth:   fio.IoDepth,
+			Size:      fio.Size,
+			NumJobs:   fio.NumJobs,
+			Runtime:   fio.Runtime,
+		}
+	}
+	if iperf3 := src.Spec.Iperf3.DeepCopy(); iperf3 != nil {
+		dst.Spec.Iperf3 = &perfv1.Iperf3Workload{
+			Topology:  perfv1.Iperf3Topology(iperf3.Topology),
+			Protocol:  iperf3.Protocol,
+			Bandwidth: iperf3.Bandwidth,
+			Parallel:  iperf3.Parallel,
+			Duration:  iperf3.Duration,
+		}
+	}
+	if http := src.Spec.HTTP.DeepCopy(); http != nil {
+		dst.Spec.HTTP = &perfv1.HTTPWorkload{
+			Servers:     http.Servers,
+			Clients:     http.Clients,
+			RPS:         http.RPS,
+			PayloadSize: http.PayloadSize,
+			Concurrency: http.Concurrency,
+		}
+	}
+	if apiServer := src.Spec.APIServer.DeepCopy(); apiServer != nil {
+		dst.Spec.APIServer = &perfv1.APIServerWorkload{
+			Namespace: apiServer.Namespace,
+			QPS:       apiServer.QPS,
+			Objects:   apiServer.Objects,
+			Clients:   apiServer.Clients,
+		}
+		for _, kind := range apiServer.Kinds {
+			dst.Spec.APIServer.Kinds = append(dst.Spec.APIServer.Kinds, perfv1.APIServerKind(kind))
+		}
+	}
+	if churn := src.Spec.Churn.DeepCopy(); churn != nil {
+		dst.Spec.Churn = &perfv1.ChurnWorkload{
+			PodsPerMinute:  churn.PodsPerMinute,
+			MaxConcurrency: churn.MaxConcurrency,
+			Lifetime:       churn.Lifetime,
+		}
+	}
+
+	stressors := src.Spec.Stressors.DeepCopy()
+	dst.Spec.Cpu = stressors.Cpu
+	dst.Spec.Memory = stressors.Memory
+	dst.Spec.Io = stressors.Io
+	dst.Spec.Sock = stressors.Sock.Workers
+	dst.Spec.SockInterface = stressors.Sock.Interface
+	dst.Spec.Hdd = stressors.Hdd.Workers
+	dst.Spec.HddBytes = stressors.Hdd.Bytes
+	dst.Spec.HddWriteSize = stressors.Hdd.WriteSize
+	if v := stressors.Hdd.Volume; v != nil {
+		dst.Spec.ScratchVolume = &perfv1.ScratchVolume{
+			Type:             perfv1.ScratchVolumeType(v.Type),
+			Size:             v.Size,
+			Path:             v.Path,
+			StorageClassName: v.StorageClassName,
+		}
+	}
+	if h := stressors.Hugepages; h != nil {
+		dst.Spec.Hugepages = &perfv1.Hugepages{
+			Size:    perfv1.HugepageSize(h.Size),
+			Amount:  h.Amount,
+			Workers: h.Workers,
+		}
+	}
+	if n := stressors.NUMA; n != nil {
+		dst.Spec.NUMA = &perfv1.NUMA{
+			Policy:  perfv1.NUMAPolicy(n.Policy),
+			Nodes:   n.Nodes,
+			Workers: n.Workers,
+		}
+	}
+	dst.Spec.CustomArgs = stressors.CustomArgs
+
+	placement := src.Spec.Placement.DeepCopy()
+	dst.Spec.HostNetwork = placement.HostNetwork
+	dst.Spec.NodeSelector = placement.NodeSelector
+	dst.Spec.Tolerations = placement.Tolerations
+	dst.Spec.AllowControlPlane = placement.AllowControlPlane
+	dst.Spec.CPUSet = placement.CPUSet
+	dst.Spec.GuaranteedCPUs = placement.GuaranteedCPUs
+	if placement.ControlPlane != nil {
+		dst.Spec.ControlPlane = &perfv1.ControlPlaneLimits{
+			Cpu:    placement.ControlPlane.Cpu,
+			Memory: placement.ControlPlane.Memory,
+			Io:     placement.ControlPlane.Io,
+			Sock:   placement.ControlPlane.Sock,
+		}
+	}
+
+	dst.Spec.Suspend = src.Spec.Schedule.Suspend
+	dst.Spec.CoolDown = src.Spec.Schedule.CoolDown.DeepCopy()
+	dst.Spec.TerminationGracePeriodSeconds = src.Spec.Schedule.TerminationGracePeriodSeconds
+	if src.Spec.Schedule.DisruptionBudget != nil {
+		dst.Spec.DisruptionBudget = &perfv1.DisruptionBudget{MinAvailable: src.Spec.Schedule.DisruptionBudget.MinAvailable}
+	}
+	dst.Spec.SecurityMode = perfv1.SecurityMode(src.Spec.Security.Mode)
+	dst.Spec.SecurityContext = src.Spec.Security.Context.DeepCopy()
+	dst.Spec.Image = src.Spec.Image
+	dst.Spec.ImagePullSecrets = append([]corev1.LocalObjectReference(nil), src.Spec.ImagePullSecrets...)
+	dst.Spec.ImagePullPolicy = src.Spec.ImagePullPolicy
+	dst.Spec.Resources = *src.Spec.Resources.DeepCopy()
+	dst.Spec.PodTemplate = src.Spec.PodTemplate.DeepCopy()
+	if src.Spec.ProfileRef != nil {
+		dst.Spec.ProfileRef = &perfv1.ProfileReference{Name: src.Spec.ProfileRef.Name}
+	}
+
+	dst.Status.Command = src.Status.Command
+	dst.Status.Custom = src.Status.Custom
+	dst.Status.ControlPlaneCommand = src.Status.ControlPlaneCommand
+	dst.Status.StartTime = src.Status.StartTime.DeepCopy()
+	dst.Status.Conditions = append([]metav1.Condition(nil), src.Status.Conditions...)
+	dst.Status.AbortedNodes = nil
+	for _, a := range src.Status.AbortedNodes {
+		dst.Status.AbortedNodes = append(dst.Status.AbortedNodes, perfv1.NodeAbort{
+			Node:        a.Node,
+			Reason:      a.Reason,
+			AbortTime:   *a.AbortTime.DeepCopy(),
+			HealthyTime: a.HealthyTime.DeepCopy(),
+		})
+	}
+	dst.Status.FioResults = nil
+	for _, f := range src.Status.FioResults {
+		dst.Status.FioResults = append(dst.Status.FioResults, perfv1.FioResult{
+			Node:         f.Node,
+			ReadIOPS:     f.ReadIOPS,
+			WriteIOPS:    f.WriteIOPS,
+			ReadLatency:  f.ReadLatency,
+			WriteLatency: f.WriteLatency,
+			Time:         *f.Time.DeepCopy(),
+		})
+	}
+	dst.Status.Iperf3Results = nil
+	for _, i := range src.Status.Iperf3Results {
+		dst.Status.Iperf3Results = append(dst.Status.Iperf3Results, perfv1.Iperf3Result{
+			Source:        i.Source,
+			Destination:   i.Destination,
+			BitsPerSecond: i.BitsPerSecond,
+			Retransmits:   i.Retransmits,
+			LostPackets:   i.LostPackets,
+			Jitter:        i.Jitter,
+			Time:          *i.Time.DeepCopy(),
+		})
+	}
+	dst.Status.PinnedCPUs = nil
+	for _, p := range src.Status.PinnedCPUs {
+		dst.Status.PinnedCPUs = append(dst.Status.PinnedCPUs, perfv1.PinnedCPUs{Node: p.Node, Pod: p.Pod, CPUs: p.CPUs})
+	}
+	dst.Status.Churn = nil
+	if churn := src.Status.Churn; churn != nil {
+		dst.Status.Churn = &perfv1.ChurnStatus{
+			PodsCreated: churn.PodsCreated,
+			PodsDeleted: churn.PodsDeleted,
+			PodsFailed:  churn.PodsFailed,
+			Samples:     churn.Samples,
+			LatencyP50:  churn.LatencyP50,
+			LatencyP90:  churn.LatencyP90,
+			LatencyP99:  churn.LatencyP99,
+		}
+	}
+	return nil
+}
+
+// ConvertFrom converts from the hub (v1) version to this version
+func (dst *Baseline) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*perfv1.Baseline).DeepCopy()
+
+	dst.ObjectMeta = src.ObjectMeta
+	if src.Spec.Custom != "" {
+		if dst.Annotations == nil {
+			dst.Annotations = map[string]string{}
+		}
+		dst.Annotations[CustomAnnotation] = src.Spec.Custom
+	}
+
+	dst.Spec.Workload = WorkloadType(src.Spec.Workload)
+	if fio := src.Spec.Fio; fio != nil {
+		dst.Spec.Fio = &FioWorkload{
+			ReadWrite: fio.ReadWrite,
+			RwMixRead: fio.RwMixRead,
+			BlockSize: fio.BlockSize,
+			IoDepth:   fio.IoDepth,
+			Size:      fio.Size,
+			NumJobs:   fio.NumJobs,
+			Runtime:   fio.Runtime,
+		}
+	}
+	if iperf3 := src.Spec.Iperf3; iperf3 != nil {
+		dst.Spec.Iperf3 = &Iperf3Workload{
+			Topology:  Iperf3Topology(iperf3.Topology),
+			Protocol:  iperf3.Protocol,
+			Bandwidth: iperf3.Bandwidth,
+			Parallel:  iperf3.Parallel,
+			Duration:  iperf3.Duration,
+		}
+	}
+	if http := src.Spec.HTTP; http != nil {
+		dst.Spec.HTTP = &HTTPWorkload{
+			Servers:     http.Servers,
+			Clients:     http.Clients,
+			RPS:         http.RPS,
+			PayloadSize: http.PayloadSize,
+			Concurrency: http.Concurrency,
+		}
+	}
+	if apiServer := src.Spec.APIServer; apiServer != nil {
+		dst.Spec.APIServer = &APIServerWorkload{
+			Namespace: apiServer.Namespace,
+			QPS:       apiServer.QPS,
+			Objects:   apiServer.Objects,
+			Clients:   apiServer.Clients,
+		}
+		for _, kind := range apiServer.Kinds {
+			dst.Spec.APIServer.Kinds = append(dst.Spec.APIServer.Kinds, APIServerKind(kind))
+		}
+	}
+	if churn := src.Spec.Churn; churn != nil {
+		dst.Spec.Churn = &ChurnWorkload{
+			PodsPerMinute:  churn.PodsPerMinute,
+			MaxConcurrency: churn.MaxConcurrency,
+			Lifetime:       churn.Lifetime,
+		}
+	}
+	dst.Spec.Stressors = Stressors{
+		Cpu:    src.Spec.Cpu,
+		Memory: src.Spec.Memory,
+		Io:     src.Spec.Io,
+		Sock: SockStressor{
+			Workers:   src.Spec.Sock,
+			Interface: src.Spec.SockInterface,
+		},
+		Hdd: HddStressor{
+			Workers:   src.Spec.Hdd,
+			Bytes:     src.Spec.HddBytes,
+			WriteSize: src.Spec.HddWriteSize,
+		},
+		CustomArgs: src.Spec.CustomArgs,
+	}
+	if v := src.Spec.ScratchVolume; v != nil {
+		dst.Spec.Stressors.Hdd.Volume = &ScratchVolume{
+			Type:             ScratchVolumeType(v.Type),
+			Size:             v.Size,
+			Path:             v.Path,
+			StorageClassName: v.StorageClassName,
+		}
+	}
+	if h := src.Spec.Hugepages; h != nil {
+		dst.Spec.Stressors.Hugepages = &Hugepages{
+			Size:    HugepageSize(h.Size),
+			Amount:  h.Amount,
+			Workers: h.Workers,
+		}
+	}
+	if n := src.Spec.NUMA; n != nil {
+		dst.Spec.Stressors.NUMA = &NUMA{
+			Policy:  NUMAPolicy(n.Policy),
+			Nodes:   n.Nodes,
+			Workers: n.Workers,
+		}
+	}
+	dst.Spec.Placement = Placement{
+		HostNetwork:       src.Spec.HostNetwork,
+		NodeSelector:      src.Spec.NodeSelector,
+		Tolerations:       src.Spec.Tolerations,
+		AllowControlPlane: src.Spec.AllowControlPlane,
+		CPUSet:            src.Spec.CPUSet,
+		GuaranteedCPUs:    src.Spec.GuaranteedCPUs,
+	}
+	if src.Spec.ControlPlane != nil {
+		dst.Spec.Placement.ControlPlane = &ControlPlaneLimits{
+			Cpu:    src.Spec.ControlPlane.Cpu,
+			Memory: src.Spec.ControlPlane.Memory,
+			Io:     src.Spec.ControlPlane.Io,
+			Sock:   src.Spec.ControlPlane.Sock,
+		}
+	}
+	dst.Spec.Schedule = Schedule{
+		Suspend:                       src.Spec.Suspend,
+		CoolDown:                      src.Spec.CoolDown,
+		TerminationGracePeriodSeconds: src.Spec.TerminationGracePeriodSeconds,
+	}
+	if src.Spec.DisruptionBudget != nil {
+		dst.Spec.Schedule.DisruptionBudget = &DisruptionBudget{MinAvailable: src.Spec.DisruptionBudget.MinAvailable}
+	}
+	dst.Spec.Security = Security{
+		Mode:    SecurityMode(src.Spec.SecurityMode),
+		Context: src.Spec.SecurityContext,
+	}
+	dst.Spec.Image = src.Spec.Image
+	dst.Spec.ImagePullSecrets = src.Spec.ImagePullSecrets
+	dst.Spec.ImagePullPolicy = src.Spec.ImagePullPolicy
+	dst.Spec.Resources = src.Spec.Resources
+	dst.Spec.PodTemplate = src.Spec.PodTemplate
+	if src.Spec.ProfileRef != nil {
+		dst.Spec.ProfileRef = &ProfileReference{Name: src.Spec.ProfileRef.Name}
+	}
+
+	dst.Status.Command = src.Status.Command
+	dst.Status.Custom = src.Status.Custom
+	dst.Status.ControlPlaneCommand = src.Status.ControlPlaneCommand
+	dst.Status.StartTime = src.Status.StartTime
+	dst.Status.Conditions = src.Status.Conditions
+	dst.Status.AbortedNodes = nil
+	for _, a := range src.Status.AbortedNodes {
+		dst.Status.AbortedNodes = append(dst.Status.AbortedNodes, NodeAbort{
+			Node:        a.Node,
+			Reason:      a.Reason,
+			AbortTime:   a.AbortTime,
+			HealthyTime: a.HealthyTime,
+		})
+	}
+	dst.Status.FioResults = nil
+	for _, f := range src.Status.FioResults {
+		dst.Status.FioResults = append(dst.Status.FioResults, FioResult{
+			Node:         f.Node,
+			ReadIOPS:     f.ReadIOPS,
+			WriteIOPS:    f.WriteIOPS,
+			ReadLatency:  f.ReadLatency,
+			WriteLatency: f.WriteLatency,
+			Time:         f.Time,
+		})
+	}
+	dst.Status.Iperf3Results = nil
+	for _, i := range src.Status.Iperf3Results {
+		dst.Status.Iperf3Results = append(dst.Status.Iperf3Results, Iperf3Result{
+			Source:        i.Source,
+			Destination:   i.Destination,
+			BitsPerSecond: i.BitsPerSecond,
+			Retransmits:   i.Retransmits,
+			LostPackets:   i.LostPackets,
+			Jitter:        i.Jitter,
+			Time:          i.Time,
+		})
+	}
+	dst.Status.PinnedCPUs = nil
+	for _, p := range src.Status.PinnedCPUs {
+		dst.Status.PinnedCPUs = append(dst.Status.PinnedCPUs, PinnedCPUs{Node: p.Node, Pod: p.Pod, CPUs: p.CPUs})
+	}
+	dst.Status.Churn = nil
+	if churn := src.Status.Churn; churn != nil {
+		dst.Status.Churn = &ChurnStatus{
+			PodsCreated: churn.PodsCreated,
+			PodsDeleted: churn.PodsDeleted,
+			PodsFailed:  churn.PodsFailed,
+			Samples:     churn.Samples,
+			LatencyP50:  churn.LatencyP50,
+			LatencyP90:  churn.LatencyP90,
+			LatencyP99:  churn.LatencyP99,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package v2
+
+import (
+	"fmt"
+	"math/rand"
+
+	fuzz "github.com/google/gofuzz"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	perfv1 "github.com/josecastillolema/baseline-operator/api/v1"
+)
+
+var _ = Describe("Baseline conversion", func() {
+
+	const rounds = 1000
+
+	// newFuzzer returns a fuzzer filling the specs, the statuses and the
+	// annotations, with valid quantities and raw pod templates
+	newFuzzer := func(seed int64) *fuzz.Fuzzer {
+		return fuzz.New().NilChance(0.2).RandSource(rand.NewSource(seed)).Funcs(
+			func(q *resource.Quantity, c fuzz.Continue) {
+				*q = *resource.NewQuantity(c.Int63n(1<<40), resource.BinarySI)
+			},
+			func(r *runtime.RawExtension, c fuzz.Continue) {
+				r.Raw = []byte(fmt.Sprintf(`{"metadata":{"annotations":{"fuzz":"%d"}}}`, c.Int63()))
+			},
+		)
+	}
+
+	Context("Converting a v1 Baseline to v2 and back", func() {
+		It("Should not lose any field", func() {
+			f := newFuzzer(GinkgoRandomSeed())
+			for i := 0; i < rounds; i++ {
+				original := &perfv1.Baseline{}
+				f.Fuzz(&original.Spec)
+				f.Fuzz(&original.Status)
+				f.Fuzz(&original.Annotations)
+
+				spoke := &Baseline{}
+				Expect(spoke.ConvertFrom(original.DeepCopy())).Should(Succeed())
+				hub := &perfv1.Baseline{}
+				Expect(spoke.ConvertTo(hub)).Should(Succeed())
+
+				Expect(equality.Semantic.DeepEqual(original, hub)).Should(BeTrue(), "%+v != %+v", original, hub)
+			}
+		})
+	})
+
+	Context("Converting a v2 Baseline to v1 and back", func() {
+		It("Should not lose any field", func() {
+			f := newFuzzer(GinkgoRandomSeed())
+			for i := 0; i < rounds; i++ {
+				original := &Baseline{}
+				f.Fuzz(&original.Spec)
+				f.Fuzz(&original.Status)
+				f.Fuzz(&original.Annotations)
+
+				hub := &perfv1.Baseline{}
+				Expect(original.DeepCopy().ConvertTo(hub)).Should(Succeed())
+				spoke := &Baseline{}
+				Expect(spoke.ConvertFrom(hub)).Should(Succeed())
+
+				Expect(equality.Semantic.DeepEqual(original, spoke)).Should(BeTrue(), "%+v != %+v", original, spoke)
+			}
+		})
+	})
+
+	Context("Converting a v1 Baseline with the deprecated custom string", func() {
+		It("Should keep it in an annotation", func() {
+			hub := &perfv1.Baseline{Spec: perfv1.BaselineSpec{Custom: "--timer 1"}}
+			spoke := &Baseline{}
+			Expect(spoke.ConvertFrom(hub)).Should(Succeed())
+			Expect(spoke.Annotations).Should(HaveKeyWithValue(CustomAnnotation, "--timer 1"))
+
+			back := &perfv1.Baseline{}
+			Expect(spoke.ConvertTo(back)).Should(Succeed())
+			Expect(back.Spec.Custom).Should(Equal("--timer 1"))
+			Expect(back.Annotations).ShouldNot(HaveKey(CustomAnnotation))
+		})
+	})
+})
@@ -0,0 +1,86 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// apiload is the load generator of the APIServer workload.
+//
+//	apiload --namespace sandbox --qps 20 --kinds ConfigMaps,Secrets,Pods --objects 100
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"k8s.io/client-go/kubernetes"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/josecastillolema/baseline-operator/pkg/apiload"
+)
+
+func main() {
+	var o apiload.Options
+	var qps float64
+	var kinds string
+	var metricsAddr string
+	flag.StringVar(&o.Namespace, "namespace", os.Getenv("POD_NAMESPACE"), "The sandbox namespace the objects are created in.")
+	flag.StringVar(&o.Name, "name", os.Getenv("POD_NAME"), "The prefix of the objects created.")
+	flag.Float64Var(&qps, "qps", 10, "The requests sent per second.")
+	flag.StringVar(&kinds, "kinds", apiload.ConfigMaps, "The comma separated kinds of objects: ConfigMaps, Secrets or Pods.")
+	flag.IntVar(&o.Objects, "objects", 100, "The objects of each kind kept in the namespace.")
+	flag.StringVar(&metricsAddr, "metrics-bind-address", ":9090", "The address the metric endpoint binds to.")
+	flag.Parse()
+	o.QPS = float32(qps)
+	o.Kinds = strings.Split(kinds, ",")
+
+	cfg := ctrl.GetConfigOrDie()
+	// The rate is set by the generator
+	cfg.QPS = -1
+	cs, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(apiload.RequestDuration)
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	srv := &http.Server{Addr: metricsAddr, Handler: mux}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Printf("metrics server stopped: %v", err)
+		}
+	}()
+
+	log.Printf("sending %v requests per second to %s", qps, o.Namespace)
+	err = apiload.Run(ctx, cs, o)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_ = srv.Shutdown(shutdownCtx)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,192 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// httpbench is the echo server and the load generator of the HTTP workload.
+//
+//	httpbench server --bind-address :8080
+//	httpbench load --url http://server:8080/ --rps 100 --payload-size 1024 --concurrency 10
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	// requestDuration is the latency of the requests per status code
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "baseline_http_request_duration_seconds",
+		Help:    "Latency of the requests sent to the echo servers",
+		Buckets: prometheus.ExponentialBuckets(0.0005, 2, 14),
+	}, []string{"code"})
+	// droppedRequests counts the requests not sent because every worker
+	// was busy
+	droppedRequests = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "baseline_http_dropped_requests_total",
+		Help: "Requests not sent because the concurrency limit was reached",
+	})
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, "usage: httpbench server|load [flags]")
+		os.Exit(2)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	var err error
+	switch os.Args[1] {
+	case "server":
+		err = server(ctx, os.Args[2:])
+	case "load":
+		err = load(ctx, os.Args[2:])
+	default:
+		err = fmt.Errorf("unknown command %q", os.Args[1])
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// server echoes the body of every request back until the context is done
+func server(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("server", flag.ExitOnError)
+	addr := fs.String("bind-address", ":8080", "The address the echo server binds to.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > 0 {
+			w.Header().Set("Content-Length", strconv.FormatInt(r.ContentLength, 10))
+		}
+		if _, err := io.Copy(w, r.Body); err != nil {
+			log.Printf("failed to echo the request: %v", err)
+		}
+	})
+	return serve(ctx, &http.Server{Addr: *addr, Handler: mux})
+}
+
+// load sends requests to the echo servers at a steady rate until the
+// context is done, exposing their latency as metrics
+func load(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("load", flag.ExitOnError)
+	url := fs.String("url", "", "The URL of the echo servers.")
+	rps := fs.Int("rps", 100, "The requests sent per second.")
+	payloadSize := fs.Int("payload-size", 1024, "The size in bytes of the body of each request.")
+	concurrency := fs.Int("concurrency", 10, "The requests kept in flight at most.")
+	metricsAddr := fs.String("metrics-bind-address", ":9090", "The address the metric endpoint binds to.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *url == "" || *rps < 1 || *concurrency < 1 || *payloadSize < 0 {
+		return fmt.Errorf("a url, a positive rps and concurrency, and a payload size are required")
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(requestDuration, droppedRequests)
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	go func() {
+		if err := serve(ctx, &http.Server{Addr: *metricsAddr, Handler: mux}); err != nil {
+			log.Printf("metrics server stopped: %v", err)
+		}
+	}()
+
+	payload := bytes.Repeat([]byte("x"), *payloadSize)
+	client := &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: &http.Transport{MaxIdleConnsPerHost: *concurrency},
+	}
+	requests := make(chan struct{}, *concurrency)
+	for i := 0; i < *concurrency; i++ {
+		go func() {
+			for range requests {
+				send(ctx, client, *url, payload)
+			}
+		}()
+	}
+
+	log.Printf("sending %d requests per second of %d bytes to %s", *rps, *payloadSize, *url)
+	ticker := time.NewTicker(time.Second / time.Duration(*rps))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			close(requests)
+			return nil
+		case <-ticker.C:
+			select {
+			case requests <- struct{}{}:
+			default:
+				droppedRequests.Inc()
+			}
+		}
+	}
+}
+
+// send sends a request and records its latency
+func send(ctx context.Context, client *http.Client, url string, payload []byte) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return
+	}
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		if ctx.Err() == nil {
+			requestDuration.WithLabelValues("error").Observe(time.Since(start).Seconds())
+		}
+		return
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	requestDuration.WithLabelValues(strconv.Itoa(resp.StatusCode)).Observe(time.Since(start).Seconds())
+}
+
+// serve runs the server until the context is done
+func serve(ctx context.Context, srv *http.Server) error {
+	errs := make(chan error, 1)
+	go func() {
+		errs <- srv.ListenAndServe()
+	}()
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return srv.Shutdown(shutdownCtx)
+	}
+}
@@ -0,0 +1,374 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	perfv1 "github.com/josecastillolema/baseline-operator/api/v1"
+)
+
+const (
+	// apiLoadName names the ServiceAccount, Role, RoleBinding and Deployment
+	// of the load generators in the sandbox namespace
+	apiLoadName = "apiload"
+	// apiLoadPath is the path of the apiload binary in the operator image
+	apiLoadPath = "/apiload"
+	// sandboxRequeue is how often a sandbox namespace still terminating is
+	// checked before being created again
+	sandboxRequeue = 5 * time.Second
+)
+
+// apiServerSpec returns the control plane load of the Baseline with its defaults
+func apiServerSpec(b *perfv1.Baseline) perfv1.APIServerWorkload {
+	apiServer := perfv1.APIServerWorkload{}
+	if b.Spec.APIServer != nil {
+		apiServer = *b.Spec.APIServer.DeepCopy()
+	}
+	if apiServer.Namespace == "" {
+		apiServer.Namespace = fmt.Sprintf("baseline-%s-%s", b.Namespace, b.Name)
+	}
+	if apiServer.QPS == 0 {
+		apiServer.QPS = 10
+	}
+	if len(apiServer.Kinds) == 0 {
+		apiServer.Kinds = []perfv1.APIServerKind{"ConfigMaps"}
+	}
+	if apiServer.Objects == 0 {
+		apiServer.Objects = 100
+	}
+	if apiServer.Clients == 0 {
+		apiServer.Clients = 1
+	}
+	return apiServer
+}
+
+// labelsForSandbox returns the labels of the sandbox namespaces of a
+// Baseline, which cannot be owned by it
+func labelsForSandbox(b *perfv1.Baseline) map[string]string {
+	ls := labelsForBaseline(b.Name)
+	ls["baseline_namespace"] = b.Namespace
+	return ls
+}
+
+// apiLoadArgs returns the arguments of the load generators
+func apiLoadArgs(b *perfv1.Baseline) []string {
+	apiServer := apiServerSpec(b)
+	var kinds []string
+	for _, kind := range apiServer.Kinds {
+		kinds = append(kinds, string(kind))
+	}
+	return []string{"--namespace", apiServer.Namespace,
+		"--qps", strconv.Itoa(int(apiServer.QPS)),
+		"--kinds", strings.Join(kinds, ","),
+		"--objects", strconv.Itoa(int(apiServer.Objects)),
+		"--metrics-bind-address", fmt.Sprintf(":%d", httpMetricsPort)}
+}
+
+// apiLoadRole returns the permissions of the load generators in the sandbox
+func apiLoadRole() []rbacv1.PolicyRule {
+	return []rbacv1.PolicyRule{{
+		APIGroups: []string{""},
+		Resources: []string{"configmaps", "secrets", "pods"},
+		Verbs:     []string{"get", "list", "create", "update", "delete"},
+	}}
+}
+
+// apiLoadDeployment returns the Deployment of the load generators
+func apiLoadDeployment(b *perfv1.Baseline, affinity *corev1.Affinity) *appsv1.Deployment {
+	apiServer := apiServerSpec(b)
+	ls := labelsForSandbox(b)
+	ls["baseline_role"] = "client"
+	replicas := apiServer.Clients
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      apiLoadName,
+			Namespace: apiServer.Namespace,
+			Labels:    ls,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: ls,
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: ls,
+				},
+				Spec: corev1.PodSpec{
+					ServiceAccountName:            apiLoadName,
+					NodeSelector:                  b.Spec.NodeSelector,
+					Affinity:                      affinity,
+					Tolerations:                   b.Spec.Tolerations,
+					TerminationGracePeriodSeconds: terminationGracePeriod(b),
+					ImagePullSecrets:              b.Spec.ImagePullSecrets,
+					Containers: []corev1.Container{{
+						Name:            "load-generator",
+						Image:           b.Spec.Image,
+						ImagePullPolicy: b.Spec.ImagePullPolicy,
+						Command:         []string{apiLoadPath},
+						Args:            apiLoadArgs(b),
+						Resources:       b.Spec.Resources,
+						Ports:           []corev1.ContainerPort{{Name: "metrics", ContainerPort: httpMetricsPort, Protocol: corev1.ProtocolTCP}},
+						Env: []corev1.EnvVar{{
+							Name:      "POD_NAME",
+							ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"}},
+						}},
+					}},
+				},
+			},
+		},
+	}
+}
+
+// sandboxNamespace returns the sandbox namespace of the Baseline, creating
+// it when missing
+func (r *BaselineReconciler) sandboxNamespace(ctx context.Context, b *perfv1.Baseline) (*corev1.Namespace, error) {
+	log := ctrllog.FromContext(ctx)
+	namespace := apiServerSpec(b).Namespace
+
+	ns := &corev1.Namespace{}
+	err := r.Get(ctx, types.NamespacedName{Name: namespace}, ns)
+	if err == nil || !errors.IsNotFound(err) {
+		return ns, err
+	}
+	ns = &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace, Labels: labelsForSandbox(b)}}
+	log.Info("Creating the sandbox Namespace", "Namespace.Name", namespace)
+	err = r.Create(ctx, ns)
+	if err != nil {
+		log.Error(err, "Failed to create the sandbox Namespace", "Namespace.Name", namespace)
+		return nil, err
+	}
+	r.recorder.Event(b, "Normal", "Created", fmt.Sprintf("Created sandbox namespace %s", namespace))
+	return ns, nil
+}
+
+// isSandboxOf returns if the namespace is a sandbox of the Baseline, rather
+// than one the load generators must not touch
+func isSandboxOf(ns *corev1.Namespace, b *perfv1.Baseline) bool {
+	for k, v := range labelsForSandbox(b) {
+		if ns.Labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// reconcileSandbox ensures the load generators run in the sandbox namespace
+// with the permissions they need
+func (r *BaselineReconciler) reconcileSandbox(ctx context.Context, b *perfv1.Baseline, affinity *corev1.Affinity) error {
+	log := ctrllog.FromContext(ctx)
+	namespace := apiServerSpec(b).Namespace
+
+	sa := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: apiLoadName, Namespace: namespace}}
+	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, sa, func() error {
+		sa.Labels = labelsForSandbox(b)
+		return nil
+	})
+	if err != nil {
+		log.Error(err, "Failed to reconcile the ServiceAccount", "ServiceAccount.Namespace", namespace, "ServiceAccount.Name", apiLoadName)
+		return err
+	}
+	role := &rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: apiLoadName, Namespace: namespace}}
+	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, role, func() error {
+		role.Labels = labelsForSandbox(b)
+		role.Rules = apiLoadRole()
+		return nil
+	})
+	if err != nil {
+		log.Error(err, "Failed to reconcile the Role", "Role.Namespace", namespace, "Role.Name", apiLoadName)
+		return err
+	}
+	binding := &rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: apiLoadName, Namespace: namespace}}
+	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, binding, func() error {
+		binding.Labels = labelsForSandbox(b)
+		binding.RoleRef = rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: apiLoadName}
+		binding.Subjects = []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: apiLoadName, Namespace: namespace}}
+		return nil
+	})
+	if err != nil {
+		log.Error(err, "Failed to reconcile the RoleBinding", "RoleBinding.Namespace", namespace, "RoleBinding.Name", apiLoadName)
+		return err
+	}
+
+	err = r.copyPullSecrets(ctx, b, namespace)
+	if err != nil {
+		return err
+	}
+
+	desired := apiLoadDeployment(b, affinity)
+	dep := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: apiLoadName, Namespace: namespace}}
+	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, dep, func() error {
+		dep.Labels = desired.Labels
+		dep.Spec.Replicas = desired.Spec.Replicas
+		if dep.Spec.Selector == nil {
+			dep.Spec.Selector = desired.Spec.Selector
+		}
+		dep.Spec.Template.Labels = desired.Spec.Template.Labels
+		found := &dep.Spec.Template.Spec
+		if len(found.Containers) == 0 || podSpecChanged(found, &desired.Spec.Template.Spec) ||
+			found.ServiceAccountName != desired.Spec.Template.Spec.ServiceAccountName {
+			dep.Spec.Template.Spec = desired.Spec.Template.Spec
+		}
+		return nil
+	})
+	if err != nil {
+		log.Error(err, "Failed to reconcile the Deployment", "Deployment.Namespace", namespace, "Deployment.Name", apiLoadName)
+		return err
+	}
+
+	svc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: apiLoadName + "-metrics", Namespace: namespace}}
+	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, svc, func() error {
+		svc.Labels = desired.Labels
+		svc.Spec.Selector = desired.Spec.Template.Labels
+		ports := []corev1.ServicePort{{Name: "metrics", Port: httpMetricsPort, TargetPort: intstr.FromString("metrics"), Protocol: corev1.ProtocolTCP}}
+		if len(svc.Spec.Ports) != 1 || !equality.Semantic.DeepEqual(svc.Spec.Ports[0].TargetPort, ports[0].TargetPort) {
+			svc.Spec.Ports = ports
+		}
+		return nil
+	})
+	if err != nil {
+		log.Error(err, "Failed to reconcile the Service", "Service.Namespace", namespace, "Service.Name", svc.Name)
+		return err
+	}
+	return nil
+}
+
+// deleteSandboxes deletes the sandbox namespaces of the Baseline but the
+// given one, along with everything the load generators created in them
+func (r *BaselineReconciler) deleteSandboxes(ctx context.Context, b *perfv1.Baseline, keep string) error {
+	log := ctrllog.FromContext(ctx)
+
+	namespaces := &corev1.NamespaceList{}
+	err := r.List(ctx, namespaces, client.MatchingLabels(labelsForSandbox(b)))
+	if err != nil {
+		log.Error(err, "Failed to list the sandbox Namespaces")
+		return err
+	}
+	for i := range namespaces.Items {
+		ns := &namespaces.Items[i]
+		if ns.Name == keep || !ns.DeletionTimestamp.IsZero() {
+			continue
+		}
+		log.Info("Deleting the sandbox Namespace", "Namespace.Name", ns.Name)
+		err = r.Delete(ctx, ns)
+		if err != nil && !errors.IsNotFound(err) {
+			log.Error(err, "Failed to delete the sandbox Namespace", "Namespace.Name", ns.Name)
+			return err
+		}
+		r.recorder.Event(b, "Normal", "Deleted", fmt.Sprintf("Deleted sandbox namespace %s", ns.Name))
+	}
+	return nil
+}
+
+// runAPIServer runs the load generators churning objects in the sandbox
+// namespace of the Baseline
+func (r *BaselineReconciler) runAPIServer(ctx context.Context, b *perfv1.Baseline, affinity *corev1.Affinity) (ctrl.Result, error) {
+	log := ctrllog.FromContext(ctx)
+
+	err := r.deleteDaemonSets(ctx, b)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	err = r.reconcileDeployments(ctx, b, nil, nil)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	ns, err := r.sandboxNamespace(ctx, b)
+	if err != nil {
+		log.Error(err, "Failed to get the sandbox Namespace")
+		return ctrl.Result{}, err
+	}
+	if !isSandboxOf(ns, b) {
+		return r.invalidSpec(b, fmt.Errorf("namespace %s already exists and is not a sandbox of the Baseline", ns.Name))
+	}
+	if !ns.DeletionTimestamp.IsZero() {
+		log.Info("Waiting for the previous sandbox Namespace to terminate", "Namespace.Name", ns.Name)
+		return ctrl.Result{RequeueAfter: sandboxRequeue}, nil
+	}
+	err = r.reconcileSandbox(ctx, b, affinity)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	err = r.updateDeploymentsCommand(ctx, b, append([]string{apiLoadPath}, apiLoadArgs(b)...))
+	if err != nil {
+		log.Error(err, "Failed to update Baseline status")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// copyPullSecrets copies the image pull secrets of the Baseline to the
+// sandbox namespace, as the pods can only reference secrets of their own
+// namespace. The secrets are read directly, not to cache every secret of
+// the cluster
+func (r *BaselineReconciler) copyPullSecrets(ctx context.Context, b *perfv1.Baseline, namespace string) error {
+	log := ctrllog.FromContext(ctx)
+	if r.KubeClient == nil {
+		return nil
+	}
+	secrets := r.KubeClient.CoreV1().Secrets(namespace)
+	for _, ref := range b.Spec.ImagePullSecrets {
+		src, err := r.KubeClient.CoreV1().Secrets(b.Namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+		if err != nil {
+			log.Error(err, "Failed to get the image pull secret", "Secret.Namespace", b.Namespace, "Secret.Name", ref.Name)
+			return err
+		}
+		found, err := secrets.Get(ctx, ref.Name, metav1.GetOptions{})
+		if err == nil && found.Type != src.Type {
+			// The type of a secret is immutable, the copy is created again
+			err = secrets.Delete(ctx, ref.Name, metav1.DeleteOptions{})
+			if err == nil {
+				err = errors.NewNotFound(corev1.Resource("secrets"), ref.Name)
+			}
+		}
+		if errors.IsNotFound(err) {
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: ref.Name, Namespace: namespace, Labels: labelsForSandbox(b)},
+				Type:       src.Type,
+				Data:       src.Data,
+			}
+			_, err = secrets.Create(ctx, secret, metav1.CreateOptions{})
+		} else if err == nil && !equality.Semantic.DeepEqual(found.Data, src.Data) {
+			found.Data = src.Data
+			_, err = secrets.Update(ctx, found, metav1.UpdateOptions{})
+		}
+		if err != nil {
+			log.Error(err, "Failed to copy the image pull secret", "Secret.Namespace", namespace, "Secret.Name", ref.Name)
+			return err
+		}
+	}
+	return nil
+}
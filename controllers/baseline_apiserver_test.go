@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/envtest/komega"
+
+	perfv1 "github.com/josecastillolema/baseline-operator/api/v1"
+)
+
+var _ = Describe("APIServer workload", func() {
+
+	const (
+		BaselineName      = "test-apiserver-baseline"
+		BaselineNamespace = "default"
+		SandboxNamespace  = "baseline-default-test-apiserver-baseline"
+	)
+
+	Context("Rendering the load generator arguments", func() {
+		It("Should fill the defaults and target the sandbox namespace", func() {
+			b := &perfv1.Baseline{
+				ObjectMeta: metav1.ObjectMeta{Name: "churn", Namespace: "perf"},
+				Spec: perfv1.BaselineSpec{
+					Workload:  perfv1.WorkloadAPIServer,
+					APIServer: &perfv1.APIServerWorkload{QPS: 50, Kinds: []perfv1.APIServerKind{"ConfigMaps", "Pods"}},
+				},
+			}
+			Expect(apiLoadArgs(b)).Should(Equal([]string{"--namespace", "baseline-perf-churn",
+				"--qps", "50",
+				"--kinds", "ConfigMaps,Pods",
+				"--objects", "100",
+				"--metrics-bind-address", ":9090"}))
+		})
+	})
+
+	Context("Running the APIServer workload", func() {
+		It("Should provision the sandbox and delete it with the Baseline", func() {
+			ctx := context.Background()
+			baseline := &perfv1.Baseline{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      BaselineName,
+					Namespace: BaselineNamespace,
+				},
+				Spec: perfv1.BaselineSpec{
+					Workload:  perfv1.WorkloadAPIServer,
+					APIServer: &perfv1.APIServerWorkload{Clients: 2},
+				},
+			}
+			Expect(k8sClient.Create(ctx, baseline)).Should(Succeed())
+
+			ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: SandboxNamespace}}
+			Eventually(komega.Object(ns)).Should(HaveField("Labels", HaveKeyWithValue("baseline_cr", BaselineName)))
+
+			binding := &rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: "apiload", Namespace: SandboxNamespace}}
+			Eventually(komega.Object(binding)).Should(HaveField("Subjects", ContainElement(HaveField("Name", "apiload"))))
+
+			dep := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "apiload", Namespace: SandboxNamespace}}
+			Eventually(komega.Object(dep)).Should(HaveField("Spec.Replicas", HaveValue(Equal(int32(2)))))
+			Expect(dep.Spec.Template.Spec.ServiceAccountName).Should(Equal("apiload"))
+			Expect(dep.Spec.Template.Spec.Containers[0].Image).Should(Equal(perfv1.DefaultToolsImage))
+
+			By("Deleting the Baseline")
+			Expect(k8sClient.Delete(ctx, baseline)).Should(Succeed())
+			Eventually(komega.Object(ns)).Should(HaveField("DeletionTimestamp", Not(BeNil())))
+		})
+	})
+})
@@ -0,0 +1,344 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	perfv1 "github.com/josecastillolema/baseline-operator/api/v1"
+)
+
+const (
+	// churnSamples is how many recent pods the latency percentiles are
+	// computed from
+	churnSamples = 1000
+	// churnStartTimeout is how long a churn pod may take to run before it
+	// is deleted as failed
+	churnStartTimeout = 5 * time.Minute
+	// churnStatusInterval is how often the churn statistics are written to
+	// the Baseline status
+	churnStatusInterval = 10 * time.Second
+	// defaultChurnLifetime is how long the churn pods run when not set
+	defaultChurnLifetime = 10 * time.Second
+)
+
+// churnState is the churn of a Baseline, kept in memory between reconciles
+type churnState struct {
+	// nextCreate is when the next pod may be created
+	nextCreate time.Time
+	// created is when each pod was created, as seen by the operator
+	created map[types.UID]time.Time
+	// running is when each pod was first seen running
+	running map[types.UID]time.Time
+	// latencies are the latencies of the recent pods, oldest first
+	latencies []time.Duration
+	// status are the statistics to write to the Baseline status
+	status perfv1.ChurnStatus
+	// updated is when the statistics were last written
+	updated time.Time
+}
+
+// churnTracker holds the churn state of every Baseline
+type churnTracker struct {
+	mu     sync.Mutex
+	states map[types.NamespacedName]*churnState
+}
+
+// state returns the churn state of the Baseline, resuming the counters of
+// its status after a restart of the operator
+func (t *churnTracker) state(b *perfv1.Baseline) *churnState {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	key := types.NamespacedName{Namespace: b.Namespace, Name: b.Name}
+	if s, ok := t.states[key]; ok {
+		return s
+	}
+	s := &churnState{created: map[types.UID]time.Time{}, running: map[types.UID]time.Time{}}
+	if b.Status.Churn != nil {
+		s.status = *b.Status.Churn
+		s.status.Samples = 0
+	}
+	if t.states == nil {
+		t.states = map[types.NamespacedName]*churnState{}
+	}
+	t.states[key] = s
+	return s
+}
+
+// forget drops the churn state of the Baseline
+func (t *churnTracker) forget(b *perfv1.Baseline) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.states, types.NamespacedName{Namespace: b.Namespace, Name: b.Name})
+}
+
+// record adds the latency of a pod to the recent ones
+func (s *churnState) record(latency time.Duration) {
+	s.latencies = append(s.latencies, latency)
+	if len(s.latencies) > churnSamples {
+		s.latencies = s.latencies[len(s.latencies)-churnSamples:]
+	}
+}
+
+// percentile returns the percentile of the sorted latencies
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i]
+}
+
+// updateStatistics computes the percentiles of the recent latencies
+func (s *churnState) updateStatistics() {
+	sorted := append([]time.Duration(nil), s.latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	s.status.Samples = int32(len(sorted))
+	s.status.LatencyP50 = metav1.Duration{Duration: percentile(sorted, 0.50)}
+	s.status.LatencyP90 = metav1.Duration{Duration: percentile(sorted, 0.90)}
+	s.status.LatencyP99 = metav1.Duration{Duration: percentile(sorted, 0.99)}
+}
+
+// churnSpec returns the pod churn of the Baseline with its defaults
+func churnSpec(b *perfv1.Baseline) perfv1.ChurnWorkload {
+	churn := perfv1.ChurnWorkload{}
+	if b.Spec.Churn != nil {
+		churn = *b.Spec.Churn.DeepCopy()
+	}
+	if churn.PodsPerMinute == 0 {
+		churn.PodsPerMinute = 60
+	}
+	if churn.MaxConcurrency == 0 {
+		churn.MaxConcurrency = 10
+	}
+	if churn.Lifetime == nil {
+		churn.Lifetime = &metav1.Duration{Duration: defaultChurnLifetime}
+	}
+	return churn
+}
+
+// labelsForChurn returns the labels of the churn pods of a Baseline
+func labelsForChurn(name string) map[string]string {
+	ls := labelsForBaseline(name)
+	ls["baseline_workload"] = "churn"
+	return ls
+}
+
+// churnPod returns a lightweight pod of the Baseline churn
+func (r *BaselineReconciler) churnPod(b *perfv1.Baseline, affinity *corev1.Affinity) *corev1.Pod {
+	automount := false
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: b.Name + "-churn-",
+			Namespace:    b.Namespace,
+			Labels:       labelsForChurn(b.Name),
+		},
+		Spec: corev1.PodSpec{
+			NodeSelector:                  b.Spec.NodeSelector,
+			Affinity:                      affinity,
+			Tolerations:                   b.Spec.Tolerations,
+			TerminationGracePeriodSeconds: terminationGracePeriod(b),
+			AutomountServiceAccountToken:  &automount,
+			ImagePullSecrets:              b.Spec.ImagePullSecrets,
+			Containers: []corev1.Container{{
+				Name:            "churn",
+				Image:           b.Spec.Image,
+				ImagePullPolicy: b.Spec.ImagePullPolicy,
+				Resources:       b.Spec.Resources,
+			}},
+		},
+	}
+	// Set Baseline instance as the owner and controller
+	ctrl.SetControllerReference(b, pod, r.Scheme)
+	return pod
+}
+
+// startLatency returns when the pod started running and how long it took
+// since it was created. The start is the one reported by the kubelet, so
+// neither the cache lag nor the requeue delay of the operator are counted
+func startLatency(pod *corev1.Pod, created, now time.Time) (time.Time, time.Duration) {
+	started := now
+	for _, s := range pod.Status.ContainerStatuses {
+		if s.State.Running != nil {
+			started = s.State.Running.StartedAt.Time
+			break
+		}
+	}
+	latency := started.Sub(created)
+	// The kubelet reports whole seconds, which can precede the creation
+	if latency < 0 {
+		latency = 0
+	}
+	return started, latency
+}
+
+// runChurn creates the churn pods at the configured rate and deletes them
+// once they ran for their lifetime, recording how long they took to run
+func (r *BaselineReconciler) runChurn(ctx context.Context, b *perfv1.Baseline, affinity *corev1.Affinity) (ctrl.Result, error) {
+	log := ctrllog.FromContext(ctx)
+
+	err := r.deleteDaemonSets(ctx, b)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	err = r.reconcileDeployments(ctx, b, nil, nil)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	spec := churnSpec(b)
+	interval := time.Minute / time.Duration(spec.PodsPerMinute)
+	state := r.churn.state(b)
+	pods := &corev1.PodList{}
+	err = r.List(ctx, pods, client.InNamespace(b.Namespace), client.MatchingLabels(labelsForChurn(b.Name)))
+	if err != nil {
+		log.Error(err, "Failed to list the churn pods")
+		return ctrl.Result{}, err
+	}
+
+	now := time.Now()
+	requeue := interval
+	active := 0
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		active++
+		if !pod.DeletionTimestamp.IsZero() {
+			continue
+		}
+		failed := false
+		switch pod.Status.Phase {
+		case corev1.PodRunning:
+			runningSince, seen := state.running[pod.UID]
+			if !seen {
+				created := pod.CreationTimestamp.Time
+				if c, ok := state.created[pod.UID]; ok {
+					created = c
+				}
+				var latency time.Duration
+				runningSince, latency = startLatency(pod, created, now)
+				state.running[pod.UID] = runningSince
+				state.record(latency)
+				churnLatency.WithLabelValues(b.Namespace, b.Name).Observe(latency.Seconds())
+			}
+			if left := spec.Lifetime.Duration - now.Sub(runningSince); left > 0 {
+				if left < requeue {
+					requeue = left
+				}
+				continue
+			}
+		case corev1.PodFailed, corev1.PodSucceeded:
+			failed = true
+		default:
+			if now.Sub(pod.CreationTimestamp.Time) < churnStartTimeout {
+				continue
+			}
+			failed = true
+		}
+
+		err = r.Delete(ctx, pod, client.PropagationPolicy("Background"))
+		if err != nil && !errors.IsNotFound(err) {
+			log.Error(err, "Failed to delete the churn pod", "Pod.Namespace", pod.Namespace, "Pod.Name", pod.Name)
+			return ctrl.Result{}, err
+		}
+		delete(state.created, pod.UID)
+		delete(state.running, pod.UID)
+		if failed {
+			state.status.PodsFailed++
+		} else {
+			state.status.PodsDeleted++
+		}
+	}
+
+	// Do not catch up on the pods not created while at the maximum concurrency
+	if state.nextCreate.Before(now.Add(-interval)) {
+		state.nextCreate = now
+	}
+	for active < int(spec.MaxConcurrency) && !now.Before(state.nextCreate) {
+		pod := r.churnPod(b, affinity)
+		err = r.Create(ctx, pod)
+		if err != nil {
+			log.Error(err, "Failed to create the churn pod", "Pod.Namespace", b.Namespace)
+			return ctrl.Result{}, err
+		}
+		state.created[pod.UID] = time.Now()
+		state.status.PodsCreated++
+		state.nextCreate = state.nextCreate.Add(interval)
+		active++
+	}
+	if next := state.nextCreate.Sub(now); next > 0 && next < requeue {
+		requeue = next
+	}
+
+	if b.Status.Churn == nil || time.Since(state.updated) >= churnStatusInterval {
+		state.updateStatistics()
+		status := state.status
+		b.Status.Churn = &status
+		if b.Status.StartTime == nil {
+			start := metav1.Now()
+			b.Status.StartTime = &start
+		}
+		b.Status.Command = ""
+		b.Status.Custom = ""
+		err = r.updateStatus(ctx, b)
+		if err != nil {
+			log.Error(err, "Failed to update Baseline status")
+			return ctrl.Result{}, err
+		}
+		state.updated = time.Now()
+	}
+	return ctrl.Result{RequeueAfter: requeue}, nil
+}
+
+// deleteChurnPods deletes the churn pods of a Baseline no longer churning
+func (r *BaselineReconciler) deleteChurnPods(ctx context.Context, b *perfv1.Baseline) error {
+	log := ctrllog.FromContext(ctx)
+	r.churn.forget(b)
+
+	pods := &corev1.PodList{}
+	err := r.List(ctx, pods, client.InNamespace(b.Namespace), client.MatchingLabels(labelsForChurn(b.Name)))
+	if err != nil {
+		log.Error(err, "Failed to list the churn pods")
+		return err
+	}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if !pod.DeletionTimestamp.IsZero() {
+			continue
+		}
+		err = r.Delete(ctx, pod)
+		if err != nil && !errors.IsNotFound(err) {
+			log.Error(err, "Failed to delete the churn pod", "Pod.Namespace", pod.Namespace, "Pod.Name", pod.Name)
+			return err
+		}
+	}
+	return nil
+}
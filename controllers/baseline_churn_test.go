@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest/komega"
+
+	perfv1 "github.com/josecastillolema/baseline-operator/api/v1"
+)
+
+var _ = Describe("Churn workload", func() {
+
+	const (
+		BaselineName      = "test-churn-baseline"
+		BaselineNamespace = "default"
+	)
+
+	Context("Computing the latency percentiles", func() {
+		It("Should only keep the recent pods", func() {
+			s := &churnState{}
+			for i := 1; i <= churnSamples+100; i++ {
+				s.record(time.Duration(i) * time.Millisecond)
+			}
+			s.updateStatistics()
+			Expect(s.status.Samples).Should(Equal(int32(churnSamples)))
+			Expect(s.status.LatencyP50.Duration).Should(Equal(600 * time.Millisecond))
+			Expect(s.status.LatencyP99.Duration).Should(Equal(1090 * time.Millisecond))
+		})
+	})
+
+	Context("Measuring the start latency", func() {
+		It("Should use the start reported by the kubelet", func() {
+			created := time.Date(2022, 6, 1, 10, 0, 0, 500_000_000, time.UTC)
+			now := created.Add(time.Minute)
+			pod := &corev1.Pod{Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+				State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: metav1.NewTime(created.Add(1500 * time.Millisecond))}},
+			}}}}
+			started, latency := startLatency(pod, created, now)
+			Expect(started).Should(Equal(created.Add(1500 * time.Millisecond)))
+			Expect(latency).Should(Equal(1500 * time.Millisecond))
+
+			// The whole seconds of the kubelet can precede the creation
+			pod.Status.ContainerStatuses[0].State.Running.StartedAt = metav1.NewTime(created.Truncate(time.Second))
+			_, latency = startLatency(pod, created, now)
+			Expect(latency).Should(BeZero())
+
+			// Without a start, the pod is considered running since now
+			pod.Status.ContainerStatuses = nil
+			started, latency = startLatency(pod, created, now)
+			Expect(started).Should(Equal(now))
+			Expect(latency).Should(Equal(time.Minute))
+		})
+	})
+
+	Context("Running the Churn workload", func() {
+		It("Should create pods up to the maximum concurrency", func() {
+			ctx := context.Background()
+			baseline := &perfv1.Baseline{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      BaselineName,
+					Namespace: BaselineNamespace,
+				},
+				Spec: perfv1.BaselineSpec{
+					Workload: perfv1.WorkloadChurn,
+					Churn:    &perfv1.ChurnWorkload{PodsPerMinute: 600, MaxConcurrency: 3},
+				},
+			}
+			Expect(k8sClient.Create(ctx, baseline)).Should(Succeed())
+
+			// Without kubelets the pods never run, so they pile up to the
+			// maximum concurrency
+			pods := &corev1.PodList{}
+			Eventually(komega.ObjectList(pods, client.InNamespace(BaselineNamespace), client.MatchingLabels(labelsForChurn(BaselineName)))).
+				Should(HaveField("Items", HaveLen(3)))
+			Consistently(komega.ObjectList(pods, client.InNamespace(BaselineNamespace), client.MatchingLabels(labelsForChurn(BaselineName))), time.Second).
+				Should(HaveField("Items", HaveLen(3)))
+			Expect(pods.Items[0].Spec.Containers[0].Image).Should(Equal(perfv1.DefaultChurnImage))
+			Eventually(komega.Object(baseline)).Should(HaveField("Status.Churn.PodsCreated", BeNumerically(">=", 1)))
+		})
+	})
+})
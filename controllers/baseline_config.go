@@ -0,0 +1,87 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	configv1alpha1 "github.com/josecastillolema/baseline-operator/api/config/v1alpha1"
+	perfv1 "github.com/josecastillolema/baseline-operator/api/v1"
+)
+
+// operatorSettings are the settings of the operator configuration that are
+// reloaded while running
+type operatorSettings struct {
+	guardrails  *configv1alpha1.Guardrails
+	tolerations []corev1.Toleration
+}
+
+// Configure applies the reloadable settings of the operator configuration,
+// reconciling every Baseline again when already running
+func (r *BaselineReconciler) Configure(c *configv1alpha1.BaselineOperatorConfig) {
+	r.settingsLock.Lock()
+	r.settings = operatorSettings{guardrails: c.Guardrails, tolerations: c.DefaultTolerations}
+	r.settingsLock.Unlock()
+	if r.reload != nil {
+		// A pending reload already reconciles every Baseline
+		select {
+		case r.reload <- event.GenericEvent{Object: &perfv1.Baseline{}}:
+		default:
+		}
+	}
+}
+
+// currentSettings returns the settings of the operator configuration
+func (r *BaselineReconciler) currentSettings() operatorSettings {
+	r.settingsLock.RLock()
+	defer r.settingsLock.RUnlock()
+	return r.settings
+}
+
+// applyGuardrails fills the limits the cluster policy does not set with the
+// ones of the operator configuration
+func (r *BaselineReconciler) applyGuardrails(policy *perfv1.BaselinePolicySpec) {
+	g := r.currentSettings().guardrails
+	if g == nil {
+		return
+	}
+	if policy.MaxCpuPerNode == nil {
+		policy.MaxCpuPerNode = g.MaxCpuPerNode
+	}
+	if policy.MaxMemoryPerNode == nil {
+		policy.MaxMemoryPerNode = g.MaxMemoryPerNode
+	}
+	policy.ForbiddenNodeLabels = append(policy.ForbiddenNodeLabels, g.ForbiddenNodeLabels...)
+}
+
+// setDefaultTolerations applies the default tolerations of the operator
+// configuration to a Baseline that does not set any, an empty list opting
+// out of them. They are not stored, so they follow the configuration
+func (r *BaselineReconciler) setDefaultTolerations(b *perfv1.Baseline) {
+	if b.Spec.Tolerations == nil {
+		b.Spec.Tolerations = append([]corev1.Toleration(nil), r.currentSettings().tolerations...)
+	}
+}
+
+// baselinesForReload returns a request for every Baseline when the operator
+// configuration is reloaded
+func (r *BaselineReconciler) baselinesForReload(o client.Object) []reconcile.Request {
+	return r.allBaselines()
+}
@@ -0,0 +1,68 @@
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	configv1alpha1 "github.com/josecastillolema/baseline-operator/api/config/v1alpha1"
+	perfv1 "github.com/josecastillolema/baseline-operator/api/v1"
+)
+
+var _ = Describe("Operator configuration", func() {
+
+	Context("Applying the guardrails", func() {
+		It("Should fill the limits the cluster policy does not set", func() {
+			cpu, policyCpu := int32(8), int32(4)
+			memory := resource.MustParse("8Gi")
+			r := &BaselineReconciler{}
+			r.Configure(&configv1alpha1.BaselineOperatorConfig{Guardrails: &configv1alpha1.Guardrails{
+				MaxCpuPerNode:       &cpu,
+				MaxMemoryPerNode:    &memory,
+				ForbiddenNodeLabels: []perfv1.ForbiddenNodeLabel{{Key: "node-role.kubernetes.io/infra"}},
+			}})
+
+			policy := &perfv1.BaselinePolicySpec{
+				MaxCpuPerNode:       &policyCpu,
+				ForbiddenNodeLabels: []perfv1.ForbiddenNodeLabel{{Key: "node-role.kubernetes.io/control-plane"}},
+			}
+			r.applyGuardrails(policy)
+			Expect(*policy.MaxCpuPerNode).Should(Equal(int32(4)))
+			Expect(policy.MaxMemoryPerNode.String()).Should(Equal("8Gi"))
+			Expect(policy.ForbiddenNodeLabels).Should(HaveLen(2))
+		})
+	})
+
+	Context("Applying the default tolerations", func() {
+		It("Should only apply them to the Baselines without tolerations", func() {
+			infra := corev1.Toleration{Key: "node-role.kubernetes.io/infra", Operator: corev1.TolerationOpExists}
+			r := &BaselineReconciler{}
+			r.Configure(&configv1alpha1.BaselineOperatorConfig{DefaultTolerations: []corev1.Toleration{infra}})
+
+			b := &perfv1.Baseline{}
+			r.setDefaultTolerations(b)
+			Expect(b.Spec.Tolerations).Should(Equal([]corev1.Toleration{infra}))
+
+			own := corev1.Toleration{Key: "stress", Operator: corev1.TolerationOpExists}
+			b.Spec.Tolerations = []corev1.Toleration{own}
+			r.setDefaultTolerations(b)
+			Expect(b.Spec.Tolerations).Should(Equal([]corev1.Toleration{own}))
+
+			b.Spec.Tolerations = []corev1.Toleration{}
+			r.setDefaultTolerations(b)
+			Expect(b.Spec.Tolerations).Should(BeEmpty())
+		})
+
+		It("Should not share the tolerations of the configuration", func() {
+			infra := corev1.Toleration{Key: "node-role.kubernetes.io/infra", Operator: corev1.TolerationOpExists}
+			r := &BaselineReconciler{}
+			r.Configure(&configv1alpha1.BaselineOperatorConfig{DefaultTolerations: []corev1.Toleration{infra}})
+
+			b := &perfv1.Baseline{}
+			r.setDefaultTolerations(b)
+			b.Spec.Tolerations[0].Key = "stress"
+			Expect(r.currentSettings().tolerations).Should(Equal([]corev1.Toleration{infra}))
+		})
+	})
+})
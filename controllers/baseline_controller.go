@@ -21,20 +21,34 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
+	"golang.org/x/time/rate"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/record"
 
 	"context"
 
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/ratelimiter"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	perfv1 "github.com/josecastillolema/baseline-operator/api/v1"
 )
@@ -44,13 +58,58 @@ type BaselineReconciler struct {
 	client.Client
 	Scheme   *runtime.Scheme
 	recorder record.EventRecorder
+	// Defaulter fills the fields the defaulting webhook would have filled,
+	// for the case the webhook is disabled
+	Defaulter *perfv1.BaselineDefaulter
+	// StopTimeout is how long the pods of a deleted Baseline are waited for
+	StopTimeout time.Duration
+	// KubeClient reads the logs the fio results are taken from
+	KubeClient kubernetes.Interface
+	// ProbeImages checks the stress-ng images support the flags of the
+	// Baselines before running them
+	ProbeImages bool
+	// MaxConcurrentReconciles is how many Baselines are reconciled at once
+	MaxConcurrentReconciles int
+	// WatchNamespaces are the namespaces the operator is restricted to, all
+	// of them if empty
+	WatchNamespaces []string
+	// RateLimiter limits the reconcile requests, the default of the
+	// controllers if nil
+	RateLimiter ratelimiter.RateLimiter
+	// DaemonSetCreates caps the DaemonSets created by the operator, i.e.
+	// when every Baseline is recreated after a restart. Unlimited if nil
+	DaemonSetCreates *rate.Limiter
+
+	// settings are the reloadable settings of the operator configuration
+	settings     operatorSettings
+	settingsLock sync.RWMutex
+	// reload reconciles every Baseline when the configuration is reloaded
+	reload chan event.GenericEvent
+
+	// churn is the state of the Baselines churning pods
+	churn churnTracker
 }
 
 //+kubebuilder:rbac:groups=perf.baseline.io,resources=baselines,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=perf.baseline.io,resources=baselines/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=perf.baseline.io,resources=baselines/finalizers,verbs=update
+//+kubebuilder:rbac:groups=perf.baseline.io,resources=baselineprofiles,verbs=get;list;watch
+//+kubebuilder:rbac:groups=perf.baseline.io,resources=baselinepolicies,verbs=get;list;watch
 //+kubebuilder:rbac:groups=apps,resources=daemonsets,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch;create;update;delete
+//+kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=core,resources=pods/log,verbs=get
+//+kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;delete
+//+kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch;create;delete
+//+kubebuilder:rbac:groups=core,resources=serviceaccounts,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;create;update;delete
+//+kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=roles;rolebindings,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=perf.baseline.io,resources=events,verbs=create;patch
+//+kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -63,8 +122,7 @@ func (r *BaselineReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 	if err != nil {
 		if errors.IsNotFound(err) {
 			// Request object not found, could have been deleted after reconcile request.
-			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
-			// Return and don't requeue
+			// The workload was stopped by the finalizer. Return and don't requeue
 			log.Info("Baseline resource not found. Ignoring since object must be deleted")
 			return ctrl.Result{}, nil
 		}
@@ -73,24 +131,231 @@ func (r *BaselineReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 		return ctrl.Result{}, err
 	}
 
+	// Stop the workload of a deleted Baseline before releasing it
+	if !baseline.DeletionTimestamp.IsZero() {
+		return r.finalize(ctx, baseline)
+	}
+	if !controllerutil.ContainsFinalizer(baseline, baselineFinalizer) {
+		controllerutil.AddFinalizer(baseline, baselineFinalizer)
+		err = r.Update(ctx, baseline)
+		if err != nil {
+			log.Error(err, "Failed to add the Baseline finalizer")
+			return ctrl.Result{}, err
+		}
+	}
+
+	// The halt switch of the cluster policy stops the workload whatever the
+	// state of the Baseline, i.e. before its profile is looked up
+	policy, err := r.getPolicy(ctx)
+	if err != nil {
+		log.Error(err, "Failed to get BaselinePolicy")
+		return ctrl.Result{}, err
+	}
+	if policy.Spec.Halt {
+		return r.halt(ctx, baseline)
+	}
+	err = r.clearCondition(ctx, baseline, perfv1.ConditionHalted, "PolicyResumed")
+	if err != nil {
+		log.Error(err, "Failed to update Baseline status")
+		return ctrl.Result{}, err
+	}
+
+	// Fill the fields the Baseline does not set from its profile
+	var profile *perfv1.BaselineProfile
+	if baseline.Spec.ProfileRef != nil {
+		profile = &perfv1.BaselineProfile{}
+		err = r.Get(ctx, types.NamespacedName{Name: baseline.Spec.ProfileRef.Name}, profile)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				// The Baseline is requeued once the profile gets created
+				r.recorder.Event(baseline, "Warning", "ProfileNotFound", fmt.Sprintf("BaselineProfile %s not found", baseline.Spec.ProfileRef.Name))
+				return ctrl.Result{}, nil
+			}
+			log.Error(err, "Failed to get BaselineProfile")
+			return ctrl.Result{}, err
+		}
+	}
+
+	// The workload is rendered from a copy of the Baseline completed with
+	// its profile and the defaults, which are never stored
+	desired := baseline.DeepCopy()
+	if profile != nil {
+		applyProfile(&desired.Spec, &profile.Spec)
+	}
+	r.Defaulter.SetDefaults(desired)
+	r.setDefaultTolerations(desired)
+
+	// Enforce the cluster policy before anything gets scheduled
+	if violations := policyViolations(policy, desired); len(violations) > 0 {
+		return r.violatesPolicy(ctx, desired, violations)
+	}
+	podSecurity, err := r.podSecurityRefusal(ctx, desired)
+	if err != nil {
+		log.Error(err, "Failed to check the Pod Security level of the namespace")
+		return ctrl.Result{}, err
+	}
+	if refusal := controlPlaneRefusal(desired); refusal != "" {
+		// The workload still runs on the other nodes
+		degraded := meta.FindStatusCondition(desired.Status.Conditions, perfv1.ConditionDegraded)
+		if degraded == nil || degraded.Status != metav1.ConditionTrue || degraded.Reason != "ControlPlaneNotAllowed" {
+			r.recorder.Event(desired, "Warning", "ControlPlaneNotAllowed", refusal)
+		}
+		err = r.updateCondition(ctx, desired, metav1.Condition{
+			Type:    perfv1.ConditionDegraded,
+			Status:  metav1.ConditionTrue,
+			Reason:  "ControlPlaneNotAllowed",
+			Message: refusal,
+		})
+	} else if podSecurity != "" {
+		// The pods are rejected until the namespace or the spec change
+		degraded := meta.FindStatusCondition(desired.Status.Conditions, perfv1.ConditionDegraded)
+		if degraded == nil || degraded.Status != metav1.ConditionTrue || degraded.Reason != "PodSecurityViolation" {
+			r.recorder.Event(desired, "Warning", "PodSecurityViolation", podSecurity)
+		}
+		err = r.updateCondition(ctx, desired, metav1.Condition{
+			Type:    perfv1.ConditionDegraded,
+			Status:  metav1.ConditionTrue,
+			Reason:  "PodSecurityViolation",
+			Message: podSecurity,
+		})
+	} else {
+		err = r.clearCondition(ctx, desired, perfv1.ConditionDegraded, "Compliant")
+	}
+	if err != nil {
+		log.Error(err, "Failed to update Baseline status")
+		return ctrl.Result{}, err
+	}
+
+	// Remove the load from the unhealthy nodes until they cool down
+	changed, coolDownLeft, err := r.updateNodeHealth(ctx, desired)
+	if err != nil {
+		log.Error(err, "Failed to check the health of the nodes")
+		return ctrl.Result{}, err
+	}
+	if changed {
+		err = r.updateStatus(ctx, desired)
+		if err != nil {
+			log.Error(err, "Failed to update Baseline status")
+			return ctrl.Result{}, err
+		}
+	}
+	requirements := forbiddenNodeRequirements(policy, desired)
+	// The excluded nodes are part of the pod template, so excluding or
+	// readmitting a node rolls the pods of every node
+	excludedNodes := abortedNodeNames(desired)
+	// The control plane nodes are always excluded, so the nodes labelled as
+	// control plane later never get the load
+	affinity := nodeAffinity(requirements, excludedNodes)
+	if !desired.Spec.AllowControlPlane || controlPlaneCapped(desired) {
+		affinity = nodeAffinity(append(requirements, notControlPlane()...), excludedNodes)
+	}
+
+	// Ensure the daemonset does not exist while the Baseline is suspended
+	if desired.Spec.Suspend {
+		return r.suspend(ctx, desired)
+	}
+
+	// The sandbox namespace of the control plane load cannot be owned by
+	// the Baseline, delete it once unused
+	sandbox := ""
+	if desired.Spec.Workload == perfv1.WorkloadAPIServer {
+		sandbox = apiServerSpec(desired).Namespace
+	}
+	err = r.deleteSandboxes(ctx, desired, sandbox)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// The pod churn, the iperf3 tests, the HTTP traffic and the control plane
+	// load do not run as a daemonset
+	if desired.Spec.Workload == perfv1.WorkloadIperf3 {
+		iperf3Requirements := requirements
+		if !desired.Spec.AllowControlPlane {
+			iperf3Requirements = append(requirements, notControlPlane()...)
+		}
+		result, err := r.runIperf3(ctx, desired, iperf3Requirements, excludedNodes)
+		if err == nil && coolDownLeft > 0 && (result.RequeueAfter == 0 || coolDownLeft < result.RequeueAfter) {
+			result.RequeueAfter = coolDownLeft
+		}
+		return result, err
+	}
+	if desired.Spec.Workload == perfv1.WorkloadChurn {
+		result, err := r.runChurn(ctx, desired, affinity)
+		if err == nil && coolDownLeft > 0 && (result.RequeueAfter == 0 || coolDownLeft < result.RequeueAfter) {
+			result.RequeueAfter = coolDownLeft
+		}
+		return result, err
+	}
+	err = r.deleteChurnPods(ctx, desired)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if desired.Spec.Workload == perfv1.WorkloadAPIServer && len(r.WatchNamespaces) > 0 {
+		// The operator has no permissions in the sandbox namespace
+		return r.invalidSpec(desired, fmt.Errorf("the APIServer workload creates a sandbox namespace, which requires the operator to watch every namespace"))
+	}
+	if desired.Spec.Workload == perfv1.WorkloadHTTP || desired.Spec.Workload == perfv1.WorkloadAPIServer {
+		run := r.runHTTP
+		if desired.Spec.Workload == perfv1.WorkloadAPIServer {
+			run = r.runAPIServer
+		}
+		result, err := run(ctx, desired, affinity)
+		if err == nil && coolDownLeft > 0 && (result.RequeueAfter == 0 || coolDownLeft < result.RequeueAfter) {
+			result.RequeueAfter = coolDownLeft
+		}
+		return result, err
+	}
+	err = r.reconcileDeployments(ctx, desired, nil, nil)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Render the fio job before the pods mount it
+	err = r.reconcileFioJob(ctx, desired)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Reject the flags the stress-ng image does not support
+	probe, err := r.probeImage(ctx, desired)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if probe == nil {
+		return ctrl.Result{RequeueAfter: imageProbeRequeue}, nil
+	}
+	if err := probe.check(desired); err != nil {
+		return r.invalidSpec(desired, err)
+	}
+
 	// Check if the daemonset already exists, if not create a new one
 	found := &appsv1.DaemonSet{}
-	err = r.Get(ctx, types.NamespacedName{Name: baseline.Name, Namespace: baseline.Namespace}, found)
+	err = r.Get(ctx, types.NamespacedName{Name: desired.Name, Namespace: desired.Namespace}, found)
 	if err != nil && errors.IsNotFound(err) {
 		// Define a new daemonset
-		ds, custom := r.daemonsetForBaseline(baseline)
+		ds, err := r.daemonsetForBaseline(desired, affinity)
+		if err != nil {
+			return r.invalidSpec(desired, err)
+		}
+		delay, cancelCreate := r.reserveDaemonSetCreate()
+		if delay > 0 {
+			log.Info("Delaying the DaemonSet creation", "DaemonSet.Namespace", ds.Namespace, "DaemonSet.Name", ds.Name, "Delay", delay)
+			return ctrl.Result{RequeueAfter: delay}, nil
+		}
 		log.Info("Creating a new DaemonSet", "DaemonSet.Namespace", ds.Namespace, "DaemonSet.Name", ds.Name)
 		err = r.Create(ctx, ds)
 		if err != nil {
+			cancelCreate()
 			log.Error(err, "Failed to create new DaemonSet", "DaemonSet.Namespace", ds.Namespace, "DaemonSet.Name", ds.Name)
 			return ctrl.Result{}, err
 		}
 		// Daemonset created successfully - update status, return and requeue
-		r.recorder.Event(baseline, "Normal", "Created", fmt.Sprintf("Created daemonset %s/%s", ds.Namespace, ds.Name))
-		baseline.Status.Command = strings.Join(ds.Spec.Template.Spec.Containers[0].Command, " ")
-		baseline.Status.Custom = custom
-
-		err := r.Status().Update(ctx, baseline)
+		r.recorder.Event(desired, "Normal", "Created", fmt.Sprintf("Created daemonset %s/%s", ds.Namespace, ds.Name))
+		if desired.Status.StartTime == nil {
+			now := metav1.Now()
+			desired.Status.StartTime = &now
+		}
+		err = r.updateStatusCommand(ctx, desired, ds)
 		if err != nil {
 			log.Error(err, "Failed to update Baseline status")
 			return ctrl.Result{}, err
@@ -101,63 +366,35 @@ func (r *BaselineReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 		return ctrl.Result{}, err
 	}
 
-	// Ensure the nodeSelector and tolerations are the same as the spec
-	nodeSelector := baseline.Spec.NodeSelector
-	tolerations := baseline.Spec.Tolerations
-	image := baseline.Spec.Image
-	hostNetwork := baseline.Spec.HostNetwork
-	if !reflect.DeepEqual(found.Spec.Template.Spec.NodeSelector, nodeSelector) ||
-		!reflect.DeepEqual(found.Spec.Template.Spec.Tolerations, tolerations) ||
-		found.Spec.Template.Spec.Containers[0].Image != image ||
-		found.Spec.Template.Spec.HostNetwork != hostNetwork {
-		found.Spec.Template.Spec.NodeSelector = nodeSelector
-		found.Spec.Template.Spec.Tolerations = tolerations
-		found.Spec.Template.Spec.Containers[0].Image = image
-		found.Spec.Template.Spec.HostNetwork = hostNetwork
-		log.Info("Updating the DaemonSet with the new spec", "DaemonSet.Namespace", found.Namespace, "DaemonSet.Name", found.Name)
-		err = r.Update(ctx, found)
-		if err != nil {
-			log.Error(err, "Failed to update DaemonSet", "DaemonSet.Namespace", found.Namespace, "DaemonSet.Name", found.Name)
-			return ctrl.Result{}, err
+	// Ensure the stressng command is the same as in the spec
+	ds, err := r.daemonsetForBaseline(desired, affinity)
+	if err != nil {
+		return r.invalidSpec(desired, err)
+	}
+	if !reflect.DeepEqual(found.Spec.Template.Spec.Containers[0].Command, ds.Spec.Template.Spec.Containers[0].Command) ||
+		!reflect.DeepEqual(found.Spec.Template.Spec.Containers[0].Args, ds.Spec.Template.Spec.Containers[0].Args) {
+		// The previous DaemonSet runs until the new one can be created
+		delay, cancelCreate := r.reserveDaemonSetCreate()
+		if delay > 0 {
+			log.Info("Delaying the DaemonSet recreation", "DaemonSet.Namespace", ds.Namespace, "DaemonSet.Name", ds.Name, "Delay", delay)
+			return ctrl.Result{RequeueAfter: delay}, nil
 		}
-		r.recorder.Event(baseline, "Normal", "Updated", fmt.Sprintf("Updated daemonset %s/%s", found.Namespace, found.Name))
-	}
-
-	// Ensure the stressng parameters are the same as in the spec
-	command := found.Spec.Template.Spec.Containers[0].Command
-	// updateCpu := false
-	var cpu int32 = 0
-	if baseline.Spec.Cpu != nil {
-		cpu = *baseline.Spec.Cpu
-		//updateCpu = needForUpdateInt(command, cpu, "--cpu")
-	}
-	mem := baseline.Spec.Memory
-	io := baseline.Spec.Io
-	sock := baseline.Spec.Sock
-	custom := baseline.Spec.Custom
-	updateCpu := needForUpdateInt(command, cpu, "--cpu")
-	updateSock := needForUpdateInt(command, sock, "--sock")
-	updateIo := needForUpdateInt(command, io, "--io")
-	updateMem := needForUpdateString(command, mem, "--vm")
-	if updateCpu || updateMem || updateIo || updateSock || !strings.Contains(strings.Join(command, " "), custom) || custom != baseline.Status.Custom {
-		// Define a new daemonset
-		ds, custom := r.daemonsetForBaseline(baseline)
 		log.Info("Recreating the DaemonSet with the new command", "DaemonSet.Namespace", ds.Namespace, "DaemonSet.Name", ds.Name)
-		err = r.Delete(ctx, ds)
+		err = r.Delete(ctx, found)
 		if err != nil {
+			cancelCreate()
 			log.Error(err, "Failed to delete previous DaemonSet", "DaemonSet.Namespace", ds.Namespace, "DaemonSet.Name", ds.Name)
 			return ctrl.Result{}, err
 		}
 		err = r.Create(ctx, ds)
 		if err != nil {
+			cancelCreate()
 			log.Error(err, "Failed to recreate DaemonSet", "DaemonSet.Namespace", ds.Namespace, "DaemonSet.Name", ds.Name)
 			return ctrl.Result{}, err
 		}
 		// Daemonset recreated successfully - update status, return and requeue
-		r.recorder.Event(baseline, "Normal", "Recreated", fmt.Sprintf("Rereated daemonset %s/%s", ds.Namespace, ds.Name))
-		baseline.Status.Command = strings.Join(ds.Spec.Template.Spec.Containers[0].Command, " ")
-		baseline.Status.Custom = custom
-		err := r.Status().Update(ctx, baseline)
+		r.recorder.Event(desired, "Normal", "Recreated", fmt.Sprintf("Rereated daemonset %s/%s", ds.Namespace, ds.Name))
+		err = r.updateStatusCommand(ctx, desired, ds)
 		if err != nil {
 			log.Error(err, "Failed to update Baseline status")
 			return ctrl.Result{}, err
@@ -165,53 +402,168 @@ func (r *BaselineReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 		return ctrl.Result{Requeue: true}, nil
 	}
 
+	// Ensure the nodeSelector, affinity, tolerations, resources and pod
+	// template overlay are the same as the spec
+	if podTemplateChanged(&found.Spec.Template, &ds.Spec.Template, desired.Spec.PodTemplate, ds.Spec.Selector.MatchLabels) {
+		found.Spec.Template = ds.Spec.Template
+		log.Info("Updating the DaemonSet with the new spec", "DaemonSet.Namespace", found.Namespace, "DaemonSet.Name", found.Name)
+		err = r.Update(ctx, found)
+		if err != nil {
+			log.Error(err, "Failed to update DaemonSet", "DaemonSet.Namespace", found.Namespace, "DaemonSet.Name", found.Name)
+			return ctrl.Result{}, err
+		}
+		r.recorder.Event(desired, "Normal", "Updated", fmt.Sprintf("Updated daemonset %s/%s", found.Namespace, found.Name))
+	}
+
+	// Run the capped workload on the control plane nodes
+	controlPlaneDelay, err := r.reconcileControlPlane(ctx, desired, requirements, excludedNodes)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Protect the stress pods from voluntary disruptions
+	err = r.reconcileDisruptionBudget(ctx, desired)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Report the results of the last fio runs
+	err = r.collectFioResults(ctx, desired)
+	if err != nil {
+		log.Error(err, "Failed to update Baseline status")
+		return ctrl.Result{}, err
+	}
+
+	// Report the cpus the pinned stress pods run on
+	err = r.collectPinnedCPUs(ctx, desired)
+	if err != nil {
+		log.Error(err, "Failed to update Baseline status")
+		return ctrl.Result{}, err
+	}
+
+	// Requeue to resume the load once the next node cools down, to collect
+	// the next fio results, to create the delayed control plane daemonset,
+	// or to probe the image again after a failed probe
+	requeueAfter := coolDownLeft
+	for _, next := range []time.Duration{fioRequeue(desired), controlPlaneDelay, probe.retryAfter(time.Now())} {
+		if next > 0 && (requeueAfter == 0 || next < requeueAfter) {
+			requeueAfter = next
+		}
+	}
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// suspend deletes the daemonsets of a suspended Baseline
+func (r *BaselineReconciler) suspend(ctx context.Context, b *perfv1.Baseline) (ctrl.Result, error) {
+	log := ctrllog.FromContext(ctx)
+
+	err := r.reconcileDeployments(ctx, b, nil, nil)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	err = r.deleteSandboxes(ctx, b, "")
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	err = r.deleteChurnPods(ctx, b)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	deleted := b.Spec.Workload != perfv1.WorkloadStressNG && b.Spec.Workload != perfv1.WorkloadFio && b.Status.Command != ""
+	for _, name := range []string{b.Name, controlPlaneName(b)} {
+		found := &appsv1.DaemonSet{}
+		err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: b.Namespace}, found)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			log.Error(err, "Failed to get DaemonSet")
+			return ctrl.Result{}, err
+		}
+		log.Info("Deleting the DaemonSet of the suspended Baseline", "DaemonSet.Namespace", found.Namespace, "DaemonSet.Name", found.Name)
+		err = r.Delete(ctx, found)
+		if err != nil {
+			log.Error(err, "Failed to delete DaemonSet", "DaemonSet.Namespace", found.Namespace, "DaemonSet.Name", found.Name)
+			return ctrl.Result{}, err
+		}
+		r.recorder.Event(b, "Normal", "Suspended", fmt.Sprintf("Deleted daemonset %s/%s", found.Namespace, found.Name))
+		deleted = true
+	}
+	if !deleted {
+		return ctrl.Result{}, nil
+	}
+	b.Status.Command = ""
+	b.Status.Custom = ""
+	b.Status.ControlPlaneCommand = ""
+	err = r.updateStatus(ctx, b)
+	if err != nil {
+		log.Error(err, "Failed to update Baseline status")
+		return ctrl.Result{}, err
+	}
 	return ctrl.Result{}, nil
 }
 
-// needForUpdateInt returns if the parameter of type int32 has to be updated
-func needForUpdateInt(commands []string, value int32, param string) bool {
-	return ((value != 0) && !present(commands, param, strconv.Itoa(int(value)), 1)) ||
-		((value == 0) && strings.Contains(strings.Join(commands, " "), param))
+// updateStatusCommand records the command of the daemonset in the Baseline status
+func (r *BaselineReconciler) updateStatusCommand(ctx context.Context, b *perfv1.Baseline, ds *appsv1.DaemonSet) error {
+	args, _ := b.Spec.Args()
+	b.Status.Command = strings.Join(ds.Spec.Template.Spec.Containers[0].Args, " ")
+	b.Status.Custom = strings.Join(args, " ")
+	return r.updateStatus(ctx, b)
 }
 
-// needForUpdateString returns if the parameter of type int32 has to be updated
-func needForUpdateString(commands []string, value string, param string) bool {
-	return ((value != "") && !present(commands, param, value, 3)) ||
-		((value == "") && strings.Contains(strings.Join(commands, " "), param))
+// updateStatus writes the status of the Baseline. The API server answers
+// with the stored spec, which would replace the profile values and the
+// defaults of the desired Baseline, so its spec is kept
+func (r *BaselineReconciler) updateStatus(ctx context.Context, b *perfv1.Baseline) error {
+	spec := b.Spec.DeepCopy()
+	err := r.Status().Update(ctx, b)
+	b.Spec = *spec
+	return err
 }
 
-// present returns if the command string has the corresponding item value in the position shift
-func present(commands []string, item string, value string, shift int) bool {
-	if shift > len(commands) {
-		return false
+// updateCondition sets the condition in the Baseline status, updating the
+// status only when the condition changed
+func (r *BaselineReconciler) updateCondition(ctx context.Context, b *perfv1.Baseline, condition metav1.Condition) error {
+	existing := meta.FindStatusCondition(b.Status.Conditions, condition.Type)
+	if existing != nil && existing.Status == condition.Status &&
+		existing.Reason == condition.Reason && existing.Message == condition.Message {
+		return nil
 	}
-	for i, n := range commands {
-		if item == n {
-			if (i+shift < len(commands)) && (commands[i+shift] == value) {
-				return true
-			}
-		}
+	condition.ObservedGeneration = b.Generation
+	meta.SetStatusCondition(&b.Status.Conditions, condition)
+	return r.updateStatus(ctx, b)
+}
+
+// clearCondition sets a condition the Baseline reports to False
+func (r *BaselineReconciler) clearCondition(ctx context.Context, b *perfv1.Baseline, conditionType, reason string) error {
+	if !meta.IsStatusConditionTrue(b.Status.Conditions, conditionType) {
+		return nil
 	}
-	return false
+	return r.updateCondition(ctx, b, metav1.Condition{
+		Type:   conditionType,
+		Status: metav1.ConditionFalse,
+		Reason: reason,
+	})
 }
 
-// daemonsetForBaseline returns a baseline DaemonSet object
-func (r *BaselineReconciler) daemonsetForBaseline(b *perfv1.Baseline) (*appsv1.DaemonSet, string) {
-	ls := labelsForBaseline(b.Name)
+// invalidSpec reports a Baseline spec that can not be rendered into a
+// daemonset. It is not requeued, as only a new spec can fix it
+func (r *BaselineReconciler) invalidSpec(b *perfv1.Baseline, err error) (ctrl.Result, error) {
+	r.recorder.Event(b, "Warning", "InvalidSpec", err.Error())
+	return ctrl.Result{}, nil
+}
+
+// commandForBaseline returns the stress-ng command of the Baseline
+func commandForBaseline(b *perfv1.Baseline) ([]string, error) {
 	command := []string{"stress-ng", "-t", "0"}
-	//cpu := strconv.Itoa(int(b.Spec.Cpu))
 	if b.Spec.Cpu != nil {
 		cpu := strconv.Itoa(int(*b.Spec.Cpu))
 		command = append(command, "--cpu", cpu)
 	}
 
 	mem := b.Spec.Memory
-	io := strconv.Itoa(int(b.Spec.Io))
-	sock := strconv.Itoa(int(b.Spec.Sock))
-	custom := b.Spec.Custom
-	// if cpu != "0" {
-	// 	command = append(command, "--cpu", cpu)
-	// }
+	io := strconv.Itoa(int(b.Spec.IoWorkers()))
+	sock := strconv.Itoa(int(b.Spec.SockWorkers()))
 	if mem != "" {
 		command = append(command, "--vm", "1", "--vm-bytes", mem)
 	}
@@ -219,10 +571,114 @@ func (r *BaselineReconciler) daemonsetForBaseline(b *perfv1.Baseline) (*appsv1.D
 		command = append(command, "--io", io)
 	}
 	if sock != "0" {
-		command = append(command, "--sock", sock, "--sock-if", "eth0")
+		command = append(command, "--sock", sock, "--sock-if", b.Spec.SockInterface)
+	}
+	if b.Spec.Hdd > 0 {
+		command = append(command, "--hdd", strconv.Itoa(int(b.Spec.Hdd)))
+		if b.Spec.HddBytes != "" {
+			command = append(command, "--hdd-bytes", b.Spec.HddBytes)
+		}
+		if b.Spec.HddWriteSize != "" {
+			command = append(command, "--hdd-write-size", b.Spec.HddWriteSize)
+		}
+	}
+	hugepages, err := hugepageArgs(b)
+	if err != nil {
+		return nil, err
+	}
+	command = append(command, hugepages...)
+	command = append(command, numaArgs(b)...)
+	if b.Spec.CPUSet != "" {
+		command = append(command, "--taskset", b.Spec.CPUSet)
+	}
+	if usesScratch(b) {
+		command = append(command, "--temp-path", scratchPath)
+	}
+	args, err := b.Spec.Args()
+	if err != nil {
+		return nil, fmt.Errorf("invalid custom: %w", err)
+	}
+	numactl, err := numactlArgs(b)
+	if err != nil {
+		return nil, err
+	}
+	return append(append(numactl, command...), args...), nil
+}
+
+// containerForBaseline returns the name, the command and the arguments of
+// the container running the workload of the Baseline
+func containerForBaseline(b *perfv1.Baseline) (string, []string, []string, error) {
+	if b.Spec.Workload == perfv1.WorkloadFio {
+		command, args := fioCommand()
+		return "fio", command, args, nil
+	}
+	args, err := commandForBaseline(b)
+	if pinsCPUs(b) {
+		return "stressng", pinnedCommand(), args, err
+	}
+	return "stressng", gracefulCommand(), args, err
+}
+
+// podVolumes returns the volumes of the Baseline pods and their mounts
+func podVolumes(b *perfv1.Baseline) ([]corev1.Volume, []corev1.VolumeMount, error) {
+	volumes, mounts, err := scratchVolume(b)
+	if err != nil {
+		return nil, nil, err
 	}
-	if custom != "" {
-		command = append(command, strings.Split(custom, " ")...)
+	if b.Spec.Workload == perfv1.WorkloadFio {
+		volume, mount := fioJobVolume(b)
+		volumes = append(volumes, volume)
+		mounts = append(mounts, mount)
+	}
+	if b.Spec.Hugepages != nil {
+		volume, mount := hugepagesVolume(b)
+		volumes = append(volumes, volume)
+		mounts = append(mounts, mount)
+	}
+	sc, err := securityContextForBaseline(b)
+	if err != nil {
+		return nil, nil, err
+	}
+	if readOnlyRoot(sc) {
+		volume, mount := tmpVolume()
+		volumes = append(volumes, volume)
+		mounts = append(mounts, mount)
+	}
+	return volumes, mounts, nil
+}
+
+// resourcesForBaseline returns the resources of the stress-ng container
+func resourcesForBaseline(b *perfv1.Baseline) (corev1.ResourceRequirements, error) {
+	resources := *b.Spec.Resources.DeepCopy()
+	hugepageResources(&resources, b)
+	if err := guaranteedCPUResources(&resources, b); err != nil {
+		return corev1.ResourceRequirements{}, err
+	}
+	if b.Spec.Hugepages != nil && !requestsCPUOrMemory(&resources) {
+		return corev1.ResourceRequirements{}, fmt.Errorf("hugepages require a cpu or memory request")
+	}
+	return resources, nil
+}
+
+// daemonsetForBaseline returns a baseline DaemonSet object with the given
+// node affinity
+func (r *BaselineReconciler) daemonsetForBaseline(b *perfv1.Baseline, affinity *corev1.Affinity) (*appsv1.DaemonSet, error) {
+	ls := labelsForBaseline(b.Name)
+	name, command, args, err := containerForBaseline(b)
+	if err != nil {
+		return nil, err
+	}
+	volumes, mounts, err := podVolumes(b)
+	if err != nil {
+		return nil, err
+	}
+	sc, err := securityContextForBaseline(b)
+	if err != nil {
+		return nil, err
+	}
+	resources, err := resourcesForBaseline(b)
+	if err != nil {
+		return nil, err
 	}
 
 	ds := &appsv1.DaemonSet{
@@ -239,21 +695,84 @@ func (r *BaselineReconciler) daemonsetForBaseline(b *perfv1.Baseline) (*appsv1.D
 					Labels: ls,
 				},
 				Spec: corev1.PodSpec{
-					HostNetwork:  b.Spec.HostNetwork,
-					NodeSelector: b.Spec.NodeSelector,
-					Tolerations:  b.Spec.Tolerations,
+					HostNetwork:                   b.Spec.UsesHostNetwork(),
+					NodeSelector:                  b.Spec.NodeSelector,
+					Affinity:                      affinity,
+					Tolerations:                   b.Spec.Tolerations,
+					TerminationGracePeriodSeconds: terminationGracePeriod(b),
+					Volumes:                       volumes,
+					ImagePullSecrets:              b.Spec.ImagePullSecrets,
 					Containers: []corev1.Container{{
-						Image:   b.Spec.Image,
-						Name:    "stressng",
-						Command: command,
+						Image:           b.Spec.Image,
+						ImagePullPolicy: b.Spec.ImagePullPolicy,
+						Name:            name,
+						Command:         command,
+						Args:            args,
+						Resources:       resources,
+						VolumeMounts:    mounts,
+						SecurityContext: sc,
+						WorkingDir:      workingDir(sc),
 					}},
 				},
 			},
 		},
 	}
+	if err := applyPodTemplate(&ds.Spec.Template, b.Spec.PodTemplate, ls); err != nil {
+		return nil, err
+	}
 	// Set Baseline instance as the owner and controller
 	ctrl.SetControllerReference(b, ds, r.Scheme)
-	return ds, custom
+	return ds, nil
+}
+
+// nodeAffinity returns the node affinity requiring every one of the
+// requirements and excluding the given nodes, or nil if there is nothing
+// to require. If alternatives are given, the nodes must also match any of
+// them
+func nodeAffinity(requirements []corev1.NodeSelectorRequirement, excludedNodes []string, alternatives ...corev1.NodeSelectorRequirement) *corev1.Affinity {
+	var fields []corev1.NodeSelectorRequirement
+	if len(excludedNodes) > 0 {
+		fields = []corev1.NodeSelectorRequirement{{
+			Key:      "metadata.name",
+			Operator: corev1.NodeSelectorOpNotIn,
+			Values:   excludedNodes,
+		}}
+	}
+	var terms []corev1.NodeSelectorTerm
+	for _, alternative := range alternatives {
+		terms = append(terms, corev1.NodeSelectorTerm{
+			MatchExpressions: append(append([]corev1.NodeSelectorRequirement(nil), requirements...), alternative),
+			MatchFields:      fields,
+		})
+	}
+	if len(alternatives) == 0 && (len(requirements) > 0 || len(fields) > 0) {
+		terms = append(terms, corev1.NodeSelectorTerm{MatchExpressions: requirements, MatchFields: fields})
+	}
+	if len(terms) == 0 {
+		return nil
+	}
+	return &corev1.Affinity{
+		NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+				NodeSelectorTerms: terms,
+			},
+		},
+	}
+}
+
+// allBaselines returns a request for every Baseline
+func (r *BaselineReconciler) allBaselines() []reconcile.Request {
+	baselines := &perfv1.BaselineList{}
+	err := r.List(context.Background(), baselines)
+	if err != nil {
+		ctrllog.Log.Error(err, "Failed to list the Baselines")
+		return nil
+	}
+	requests := make([]reconcile.Request, len(baselines.Items))
+	for i, b := range baselines.Items {
+		requests[i] = reconcile.Request{NamespacedName: types.NamespacedName{Name: b.Name, Namespace: b.Namespace}}
+	}
+	return requests
 }
 
 // labelsForBaseline returns the labels for selecting the resources
@@ -266,9 +785,25 @@ func labelsForBaseline(name string) map[string]string {
 func (r *BaselineReconciler) SetupWithManager(mgr ctrl.Manager) error {
 
 	r.recorder = mgr.GetEventRecorderFor("Baseline")
+	r.reload = make(chan event.GenericEvent, 1)
+
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &perfv1.Baseline{}, profileRefField, indexProfileRef); err != nil {
+		return err
+	}
 
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&perfv1.Baseline{}).
 		Owns(&appsv1.DaemonSet{}).
+		Owns(&appsv1.Deployment{}).
+		Owns(&corev1.Service{}).
+		Owns(&corev1.Pod{}).
+		Owns(&policyv1.PodDisruptionBudget{}).
+		Owns(&corev1.ConfigMap{}).
+		Watches(&source.Kind{Type: &perfv1.BaselineProfile{}}, handler.EnqueueRequestsFromMapFunc(r.baselinesForProfile)).
+		Watches(&source.Kind{Type: &perfv1.BaselinePolicy{}}, handler.EnqueueRequestsFromMapFunc(r.baselinesForPolicy)).
+		Watches(&source.Kind{Type: &corev1.Node{}}, handler.EnqueueRequestsFromMapFunc(r.baselinesForNode), builder.WithPredicates(nodeHealthChanged)).
+		Watches(&source.Kind{Type: &corev1.Namespace{}}, handler.EnqueueRequestsFromMapFunc(r.baselinesForNamespace), builder.WithPredicates(podSecurityChanged)).
+		Watches(&source.Channel{Source: r.reload}, handler.EnqueueRequestsFromMapFunc(r.baselinesForReload)).
+		WithOptions(controller.Options{MaxConcurrentReconciles: r.MaxConcurrentReconciles, RateLimiter: r.RateLimiter}).
 		Complete(r)
 }
@@ -5,6 +5,9 @@ import (
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/envtest/komega"
@@ -24,6 +27,7 @@ var _ = Describe("Baseline controller", func() {
 		It("Should accordingly create the status field", func() {
 			By("By creating a new Baseline")
 			ctx := context.Background()
+			io, sock := int32(1), int32(1)
 			baseline := &perfv1.Baseline{
 				TypeMeta: metav1.TypeMeta{
 					APIVersion: "perf.baseline.io/v1",
@@ -36,8 +40,8 @@ var _ = Describe("Baseline controller", func() {
 				Spec: perfv1.BaselineSpec{
 					Cpu:    new(int32),
 					Memory: "1G",
-					Io:     1,
-					Sock:   1,
+					Io:     &io,
+					Sock:   &sock,
 					Custom: "--timer 1",
 					Image:  "quay.io/jcastillolema/stressng:0.14.01",
 				},
@@ -107,7 +111,7 @@ var _ = Describe("Baseline controller", func() {
 				panic("Baseline object should exist from previous test")
 			}
 
-			createdBaseline.Spec.Io = 0
+			createdBaseline.Spec.Io = nil
 			Expect(k8sClient.Update(ctx, createdBaseline)).Should(Succeed())
 			Eventually(komega.Object(createdBaseline)).Should(HaveField("Status.Command", Equal("stress-ng -t 0 --vm 1 --vm-bytes 2G --sock 1 --sock-if eth0")))
 		})
@@ -139,7 +143,8 @@ var _ = Describe("Baseline controller", func() {
 				panic("Baseline object should exist from previous test")
 			}
 
-			createdBaseline.Spec.Io = 1
+			io := int32(1)
+			createdBaseline.Spec.Io = &io
 			Expect(k8sClient.Update(ctx, createdBaseline)).Should(Succeed())
 			Eventually(komega.Object(createdBaseline)).Should(HaveField("Status.Command", Equal("stress-ng -t 0 --io 1 --sock 1 --sock-if eth0")))
 		})
@@ -155,9 +160,92 @@ var _ = Describe("Baseline controller", func() {
 				panic("Baseline object should exist from previous test")
 			}
 
-			createdBaseline.Spec.Sock = 0
+			createdBaseline.Spec.Sock = nil
 			Expect(k8sClient.Update(ctx, createdBaseline)).Should(Succeed())
 			Eventually(komega.Object(createdBaseline)).Should(HaveField("Status.Command", Equal("stress-ng -t 0 --io 1")))
 		})
 	})
+
+	Context("Adding Baseline CRD customArgs", func() {
+		It("Should accordingly update the status field", func() {
+			By("By adding arguments containing spaces")
+			baselineLookupKey := types.NamespacedName{Name: BaselineName, Namespace: BaselineNamespace}
+			createdBaseline := &perfv1.Baseline{}
+			err := k8sClient.Get(ctx, baselineLookupKey, createdBaseline)
+			if err != nil {
+				panic("Baseline object should exist from previous test")
+			}
+
+			createdBaseline.Spec.CustomArgs = []string{"--timer", "1", "--exec-method", "execve  vfork"}
+			Expect(k8sClient.Update(ctx, createdBaseline)).Should(Succeed())
+			Eventually(komega.Object(createdBaseline)).Should(HaveField("Status.Custom", Equal("--timer 1 --exec-method execve  vfork")))
+			Eventually(func() []string {
+				ds := &appsv1.DaemonSet{}
+				if err := k8sClient.Get(ctx, baselineLookupKey, ds); err != nil {
+					return nil
+				}
+				return ds.Spec.Template.Spec.Containers[0].Args
+			}).Should(Equal([]string{"stress-ng", "-t", "0", "--io", "1", "--timer", "1", "--exec-method", "execve  vfork"}))
+		})
+	})
+
+	Context("Deriving the Baseline resources", func() {
+		It("Should set the requests of the stress-ng container", func() {
+			By("By checking the DaemonSet of the existing Baseline")
+			daemonsetLookupKey := types.NamespacedName{Name: BaselineName, Namespace: BaselineNamespace}
+			Eventually(func() bool {
+				ds := &appsv1.DaemonSet{}
+				if err := k8sClient.Get(ctx, daemonsetLookupKey, ds); err != nil {
+					return false
+				}
+				requests := ds.Spec.Template.Spec.Containers[0].Resources.Requests
+				return requests.Cpu().Equal(resource.MustParse("100m")) && requests.Memory().IsZero()
+			}).Should(BeTrue())
+		})
+	})
+
+	Context("Suspending a Baseline", func() {
+		It("Should delete the DaemonSet until it is resumed", func() {
+			By("By suspending the existing Baseline")
+			baselineLookupKey := types.NamespacedName{Name: BaselineName, Namespace: BaselineNamespace}
+			createdBaseline := &perfv1.Baseline{}
+			err := k8sClient.Get(ctx, baselineLookupKey, createdBaseline)
+			if err != nil {
+				panic("Baseline object should exist from previous test")
+			}
+
+			createdBaseline.Spec.Suspend = true
+			Expect(k8sClient.Update(ctx, createdBaseline)).Should(Succeed())
+			Eventually(komega.Object(createdBaseline)).Should(HaveField("Status.Command", BeEmpty()))
+			Eventually(func() bool {
+				return errors.IsNotFound(k8sClient.Get(ctx, baselineLookupKey, &appsv1.DaemonSet{}))
+			}).Should(BeTrue())
+
+			By("By resuming the existing Baseline")
+			Expect(k8sClient.Get(ctx, baselineLookupKey, createdBaseline)).Should(Succeed())
+			createdBaseline.Spec.Suspend = false
+			Expect(k8sClient.Update(ctx, createdBaseline)).Should(Succeed())
+			Eventually(komega.Object(createdBaseline)).ShouldNot(HaveField("Status.Command", BeEmpty()))
+		})
+	})
+
+	Context("Deleting a Baseline", func() {
+		It("Should stop the DaemonSet before releasing the Baseline", func() {
+			By("By deleting the existing Baseline")
+			baselineLookupKey := types.NamespacedName{Name: BaselineName, Namespace: BaselineNamespace}
+			createdBaseline := &perfv1.Baseline{}
+			err := k8sClient.Get(ctx, baselineLookupKey, createdBaseline)
+			if err != nil {
+				panic("Baseline object should exist from previous test")
+			}
+			Expect(createdBaseline.Finalizers).Should(ContainElement(baselineFinalizer))
+			Expect(createdBaseline.Status.StartTime).ShouldNot(BeNil())
+
+			Expect(k8sClient.Delete(ctx, createdBaseline)).Should(Succeed())
+			Eventually(func() bool {
+				return errors.IsNotFound(k8sClient.Get(ctx, baselineLookupKey, &perfv1.Baseline{}))
+			}).Should(BeTrue())
+			Expect(errors.IsNotFound(k8sClient.Get(ctx, baselineLookupKey, &appsv1.DaemonSet{}))).Should(BeTrue())
+		})
+	})
 })
@@ -0,0 +1,234 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	perfv1 "github.com/josecastillolema/baseline-operator/api/v1"
+)
+
+// controlPlaneLabels are the labels identifying the control plane nodes
+var controlPlaneLabels = []string{
+	"node-role.kubernetes.io/control-plane",
+	"node-role.kubernetes.io/master",
+}
+
+// controlPlaneRefusal returns why the Baseline targets the control plane
+// nodes without allowing them, or an empty string if it does not
+func controlPlaneRefusal(b *perfv1.Baseline) string {
+	if b.Spec.AllowControlPlane {
+		return ""
+	}
+	for _, label := range controlPlaneLabels {
+		if _, ok := b.Spec.NodeSelector[label]; ok {
+			return fmt.Sprintf("nodeSelector %s targets the control plane nodes, but allowControlPlane is not set", label)
+		}
+	}
+	for _, t := range b.Spec.Tolerations {
+		if t.Operator == corev1.TolerationOpExists && t.Key == "" {
+			return "tolerations tolerate the control plane nodes, but allowControlPlane is not set"
+		}
+		for _, label := range controlPlaneLabels {
+			if t.Key == label {
+				return fmt.Sprintf("toleration %s tolerates the control plane nodes, but allowControlPlane is not set", label)
+			}
+		}
+	}
+	return ""
+}
+
+// notControlPlane returns the node requirements excluding the control plane
+// nodes
+func notControlPlane() []corev1.NodeSelectorRequirement {
+	requirements := make([]corev1.NodeSelectorRequirement, len(controlPlaneLabels))
+	for i, label := range controlPlaneLabels {
+		requirements[i] = corev1.NodeSelectorRequirement{Key: label, Operator: corev1.NodeSelectorOpDoesNotExist}
+	}
+	return requirements
+}
+
+// onControlPlane returns the alternative node requirements selecting the
+// control plane nodes
+func onControlPlane() []corev1.NodeSelectorRequirement {
+	requirements := make([]corev1.NodeSelectorRequirement, len(controlPlaneLabels))
+	for i, label := range controlPlaneLabels {
+		requirements[i] = corev1.NodeSelectorRequirement{Key: label, Operator: corev1.NodeSelectorOpExists}
+	}
+	return requirements
+}
+
+// controlPlaneCapped returns if the Baseline runs a capped workload on the
+// control plane nodes, apart from the one on the other nodes
+func controlPlaneCapped(b *perfv1.Baseline) bool {
+	return b.Spec.AllowControlPlane && b.Spec.ControlPlane != nil
+}
+
+// controlPlaneName returns the name of the daemonset running the capped
+// workload on the control plane nodes
+func controlPlaneName(b *perfv1.Baseline) string {
+	return b.Name + "-control-plane"
+}
+
+// capSpec lowers the workers of the spec to the control plane limits
+func capSpec(spec *perfv1.BaselineSpec, limits *perfv1.ControlPlaneLimits) {
+	if limits.Cpu != nil && spec.Cpu != nil && (*spec.Cpu == 0 || *spec.Cpu > *limits.Cpu) {
+		cpu := *limits.Cpu
+		spec.Cpu = &cpu
+	}
+	if limits.Memory != "" && spec.Memory != "" {
+		size, ok := perfv1.ParseSize(spec.Memory)
+		max, maxOk := perfv1.ParseSize(limits.Memory)
+		if !ok || !maxOk || size > max {
+			spec.Memory = limits.Memory
+		}
+	}
+	if limits.Io != nil && spec.IoWorkers() > *limits.Io {
+		io := *limits.Io
+		spec.Io = &io
+	}
+	if limits.Sock != nil && spec.SockWorkers() > *limits.Sock {
+		sock := *limits.Sock
+		spec.Sock = &sock
+	}
+}
+
+// controlPlaneDaemonsetForBaseline returns the daemonset running the capped
+// workload of the Baseline on the control plane nodes
+func (r *BaselineReconciler) controlPlaneDaemonsetForBaseline(b *perfv1.Baseline, requirements []corev1.NodeSelectorRequirement, excludedNodes []string) (*appsv1.DaemonSet, error) {
+	capped := b.DeepCopy()
+	capSpec(&capped.Spec, b.Spec.ControlPlane)
+	if _, derived := b.Annotations[perfv1.DerivedResourcesAnnotation]; derived {
+		capped.Spec.Resources.Requests = perfv1.DeriveRequests(&capped.Spec)
+	}
+	ds, err := r.daemonsetForBaseline(capped, nodeAffinity(requirements, excludedNodes, onControlPlane()...))
+	if err != nil {
+		return nil, err
+	}
+	ds.Name = controlPlaneName(b)
+	ds.Spec.Selector.MatchLabels = labelsForControlPlane(b.Name)
+	for k, v := range labelsForControlPlane(b.Name) {
+		ds.Spec.Template.Labels[k] = v
+	}
+	return ds, nil
+}
+
+// labelsForControlPlane returns the labels of the capped control plane pods
+func labelsForControlPlane(name string) map[string]string {
+	ls := labelsForBaseline(name)
+	ls["baseline_tier"] = "control-plane"
+	return ls
+}
+
+// reconcileControlPlane ensures the capped control plane daemonset exists
+// only while the Baseline allows and caps the control plane nodes. It
+// returns how long its creation is delayed by the creation budget
+func (r *BaselineReconciler) reconcileControlPlane(ctx context.Context, b *perfv1.Baseline, requirements []corev1.NodeSelectorRequirement, excludedNodes []string) (time.Duration, error) {
+	log := ctrllog.FromContext(ctx)
+
+	found := &appsv1.DaemonSet{}
+	err := r.Get(ctx, types.NamespacedName{Name: controlPlaneName(b), Namespace: b.Namespace}, found)
+	if err != nil && !errors.IsNotFound(err) {
+		log.Error(err, "Failed to get DaemonSet")
+		return 0, err
+	}
+	exists := err == nil
+
+	if !controlPlaneCapped(b) {
+		if exists {
+			log.Info("Deleting the control plane DaemonSet", "DaemonSet.Namespace", found.Namespace, "DaemonSet.Name", found.Name)
+			err = r.Delete(ctx, found)
+			if err != nil && !errors.IsNotFound(err) {
+				log.Error(err, "Failed to delete DaemonSet", "DaemonSet.Namespace", found.Namespace, "DaemonSet.Name", found.Name)
+				return 0, err
+			}
+		}
+		return 0, r.updateControlPlaneCommand(ctx, b, "")
+	}
+
+	ds, err := r.controlPlaneDaemonsetForBaseline(b, requirements, excludedNodes)
+	if err != nil {
+		return 0, err
+	}
+	if !exists {
+		delay, cancelCreate := r.reserveDaemonSetCreate()
+		if delay > 0 {
+			log.Info("Delaying the control plane DaemonSet creation", "DaemonSet.Namespace", ds.Namespace, "DaemonSet.Name", ds.Name, "Delay", delay)
+			return delay, nil
+		}
+		log.Info("Creating a new control plane DaemonSet", "DaemonSet.Namespace", ds.Namespace, "DaemonSet.Name", ds.Name)
+		err = r.Create(ctx, ds)
+		if err != nil {
+			cancelCreate()
+			log.Error(err, "Failed to create new DaemonSet", "DaemonSet.Namespace", ds.Namespace, "DaemonSet.Name", ds.Name)
+			return 0, err
+		}
+		r.recorder.Event(b, "Normal", "Created", fmt.Sprintf("Created daemonset %s/%s", ds.Namespace, ds.Name))
+	} else if podTemplateChanged(&found.Spec.Template, &ds.Spec.Template, b.Spec.PodTemplate, ds.Spec.Selector.MatchLabels) {
+		found.Spec.Template = ds.Spec.Template
+		log.Info("Updating the control plane DaemonSet with the new spec", "DaemonSet.Namespace", found.Namespace, "DaemonSet.Name", found.Name)
+		err = r.Update(ctx, found)
+		if err != nil {
+			log.Error(err, "Failed to update DaemonSet", "DaemonSet.Namespace", found.Namespace, "DaemonSet.Name", found.Name)
+			return 0, err
+		}
+		r.recorder.Event(b, "Normal", "Updated", fmt.Sprintf("Updated daemonset %s/%s", found.Namespace, found.Name))
+	}
+	return 0, r.updateControlPlaneCommand(ctx, b, strings.Join(ds.Spec.Template.Spec.Containers[0].Args, " "))
+}
+
+// podSpecChanged returns if the fields set by the operator differ between
+// the found and the desired pod specs
+func podSpecChanged(found, desired *corev1.PodSpec) bool {
+	return !reflect.DeepEqual(found.NodeSelector, desired.NodeSelector) ||
+		!equality.Semantic.DeepEqual(found.Affinity, desired.Affinity) ||
+		!reflect.DeepEqual(found.Tolerations, desired.Tolerations) ||
+		found.HostNetwork != desired.HostNetwork ||
+		found.Containers[0].Image != desired.Containers[0].Image ||
+		!reflect.DeepEqual(found.TerminationGracePeriodSeconds, desired.TerminationGracePeriodSeconds) ||
+		!reflect.DeepEqual(found.Containers[0].Command, desired.Containers[0].Command) ||
+		!reflect.DeepEqual(found.Containers[0].Args, desired.Containers[0].Args) ||
+		!equality.Semantic.DeepEqual(found.Containers[0].Resources, desired.Containers[0].Resources) ||
+		!equality.Semantic.DeepEqual(found.Volumes, desired.Volumes) ||
+		!equality.Semantic.DeepEqual(found.Containers[0].VolumeMounts, desired.Containers[0].VolumeMounts) ||
+		!equality.Semantic.DeepEqual(found.Containers[0].SecurityContext, desired.Containers[0].SecurityContext) ||
+		found.Containers[0].WorkingDir != desired.Containers[0].WorkingDir ||
+		!equality.Semantic.DeepEqual(found.ImagePullSecrets, desired.ImagePullSecrets) ||
+		// The pull policy is defaulted by the API server when not set
+		(desired.Containers[0].ImagePullPolicy != "" && found.Containers[0].ImagePullPolicy != desired.Containers[0].ImagePullPolicy)
+}
+
+// updateControlPlaneCommand records the control plane command in the
+// Baseline status
+func (r *BaselineReconciler) updateControlPlaneCommand(ctx context.Context, b *perfv1.Baseline, command string) error {
+	if b.Status.ControlPlaneCommand == command {
+		return nil
+	}
+	b.Status.ControlPlaneCommand = command
+	return r.updateStatus(ctx, b)
+}
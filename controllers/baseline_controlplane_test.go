@@ -0,0 +1,122 @@
+package controllers
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/envtest/komega"
+
+	perfv1 "github.com/josecastillolema/baseline-operator/api/v1"
+)
+
+var _ = Describe("Control plane", func() {
+
+	const (
+		BaselineName      = "test-controlplane-baseline"
+		BaselineNamespace = "default"
+	)
+
+	Context("Targeting the control plane nodes", func() {
+		It("Should be refused unless allowed", func() {
+			b := &perfv1.Baseline{Spec: perfv1.BaselineSpec{
+				Tolerations: []corev1.Toleration{{
+					Key:      "node-role.kubernetes.io/control-plane",
+					Operator: corev1.TolerationOpExists,
+					Effect:   corev1.TaintEffectNoSchedule,
+				}},
+			}}
+			Expect(controlPlaneRefusal(b)).ShouldNot(BeEmpty())
+
+			b.Spec.AllowControlPlane = true
+			Expect(controlPlaneRefusal(b)).Should(BeEmpty())
+		})
+
+		It("Should cap the workers to the control plane limits", func() {
+			cpu, io, sock, maxCpu, maxIo := int32(0), int32(4), int32(1), int32(2), int32(1)
+			spec := perfv1.BaselineSpec{Cpu: &cpu, Memory: "4G", Io: &io, Sock: &sock}
+			capSpec(&spec, &perfv1.ControlPlaneLimits{Cpu: &maxCpu, Memory: "1G", Io: &maxIo})
+
+			Expect(*spec.Cpu).Should(Equal(int32(2)))
+			Expect(spec.Memory).Should(Equal("1G"))
+			Expect(*spec.Io).Should(Equal(int32(1)))
+			Expect(*spec.Sock).Should(Equal(int32(1)))
+		})
+	})
+
+	Context("Selecting a control plane node", func() {
+		It("Should keep the pods away from it unless allowed", func() {
+			By("By creating a control plane node and a Baseline selecting it")
+			ctx := context.Background()
+			node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{
+				Name: "test-controlplane-node",
+				Labels: map[string]string{
+					"controlplane":                          "true",
+					"node-role.kubernetes.io/control-plane": "",
+				},
+			}}
+			Expect(k8sClient.Create(ctx, node)).Should(Succeed())
+			cpu := int32(1)
+			baseline := &perfv1.Baseline{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      BaselineName + "-refused",
+					Namespace: BaselineNamespace,
+				},
+				Spec: perfv1.BaselineSpec{
+					Cpu:          &cpu,
+					NodeSelector: map[string]string{"controlplane": "true"},
+				},
+			}
+			Expect(k8sClient.Create(ctx, baseline)).Should(Succeed())
+			ds := &appsv1.DaemonSet{ObjectMeta: metav1.ObjectMeta{Name: baseline.Name, Namespace: BaselineNamespace}}
+			Eventually(komega.Object(ds)).Should(HaveField("Spec.Template.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms",
+				ConsistOf(HaveField("MatchExpressions", ContainElement(corev1.NodeSelectorRequirement{
+					Key:      "node-role.kubernetes.io/control-plane",
+					Operator: corev1.NodeSelectorOpDoesNotExist,
+				})))))
+
+			By("By allowing the control plane")
+			Eventually(komega.Update(baseline, func() {
+				baseline.Spec.AllowControlPlane = true
+			})).Should(Succeed())
+			Eventually(komega.Object(ds)).Should(HaveField("Spec.Template.Spec.Affinity", BeNil()))
+
+			Expect(k8sClient.Delete(ctx, baseline)).Should(Succeed())
+			Expect(k8sClient.Delete(ctx, node)).Should(Succeed())
+		})
+	})
+
+	Context("Capping the control plane nodes", func() {
+		It("Should run a capped daemonset on them", func() {
+			By("By creating a Baseline allowing and capping the control plane")
+			ctx := context.Background()
+			cpu, maxCpu := int32(4), int32(1)
+			baseline := &perfv1.Baseline{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      BaselineName,
+					Namespace: BaselineNamespace,
+				},
+				Spec: perfv1.BaselineSpec{
+					Cpu:               &cpu,
+					AllowControlPlane: true,
+					ControlPlane:      &perfv1.ControlPlaneLimits{Cpu: &maxCpu},
+				},
+			}
+			Expect(k8sClient.Create(ctx, baseline)).Should(Succeed())
+			Eventually(komega.Object(baseline)).Should(HaveField("Status.ControlPlaneCommand", Equal("stress-ng -t 0 --cpu 1")))
+			Expect(baseline.Status.Command).Should(Equal("stress-ng -t 0 --cpu 4"))
+
+			ds := &appsv1.DaemonSet{ObjectMeta: metav1.ObjectMeta{Name: BaselineName + "-control-plane", Namespace: BaselineNamespace}}
+			Eventually(komega.Object(ds)).Should(HaveField("Spec.Template.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms", HaveLen(2)))
+
+			By("By removing the cap")
+			Eventually(komega.Update(baseline, func() {
+				baseline.Spec.ControlPlane = nil
+			})).Should(Succeed())
+			Eventually(komega.Object(baseline)).Should(HaveField("Status.ControlPlaneCommand", BeEmpty()))
+		})
+	})
+})
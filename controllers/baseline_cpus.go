@@ -0,0 +1,146 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	perfv1 "github.com/josecastillolema/baseline-operator/api/v1"
+)
+
+const (
+	// pinnedCPUsPrefix prefixes the log line holding the cpus a pinned
+	// stress pod is allowed to run on
+	pinnedCPUsPrefix = "PINNED_CPUS "
+	// pinnedCPUsLogBytes is the size of the beginning of the logs the
+	// pinned cpus are searched in
+	pinnedCPUsLogBytes = 16 * 1024
+)
+
+// cpusReport prints the cpus the container is allowed to run on before
+// starting stress-ng
+const cpusReport = `echo "` + pinnedCPUsPrefix + `$(sed -n 's/^Cpus_allowed_list:[[:space:]]*//p' /proc/self/status)"
+`
+
+// pinsCPUs returns if the stress pods of the Baseline are pinned to cpus
+func pinsCPUs(b *perfv1.Baseline) bool {
+	return b.Spec.Workload == perfv1.WorkloadStressNG && (b.Spec.CPUSet != "" || b.Spec.GuaranteedCPUs != nil)
+}
+
+// pinnedCommand returns the container command wrapping stress-ng, which
+// also reports the cpus the container is allowed to run on
+func pinnedCommand() []string {
+	return []string{"/bin/sh", "-c", cpusReport + gracefulWrapper, "stress-ng"}
+}
+
+// guaranteedCPUResources requests the exclusive cpus of the Baseline, if
+// any. The cpu and memory limits are set to the requests, so the pods get
+// the Guaranteed QoS class the static CPU manager pins
+func guaranteedCPUResources(resources *corev1.ResourceRequirements, b *perfv1.Baseline) error {
+	if b.Spec.GuaranteedCPUs == nil {
+		return nil
+	}
+	memory, ok := resources.Requests[corev1.ResourceMemory]
+	if !ok {
+		memory, ok = resources.Limits[corev1.ResourceMemory]
+	}
+	if !ok {
+		return fmt.Errorf("guaranteedCpus requires a memory request")
+	}
+	if resources.Limits == nil {
+		resources.Limits = corev1.ResourceList{}
+	}
+	if resources.Requests == nil {
+		resources.Requests = corev1.ResourceList{}
+	}
+	cpus := *resource.NewQuantity(int64(*b.Spec.GuaranteedCPUs), resource.DecimalSI)
+	resources.Requests[corev1.ResourceCPU] = cpus
+	resources.Limits[corev1.ResourceCPU] = cpus
+	resources.Requests[corev1.ResourceMemory] = memory
+	resources.Limits[corev1.ResourceMemory] = memory
+	return nil
+}
+
+// parsePinnedCPUs returns the cpus logged by a pinned stress pod
+func parsePinnedCPUs(logs []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(logs))
+	for scanner.Scan() {
+		if line := scanner.Text(); strings.HasPrefix(line, pinnedCPUsPrefix) {
+			return strings.TrimSpace(strings.TrimPrefix(line, pinnedCPUsPrefix))
+		}
+	}
+	return ""
+}
+
+// collectPinnedCPUs records in the status the cpus every pinned stress pod
+// of the Baseline runs on. The logs of a pod are only read once
+func (r *BaselineReconciler) collectPinnedCPUs(ctx context.Context, b *perfv1.Baseline) error {
+	log := ctrllog.FromContext(ctx)
+	if r.KubeClient == nil {
+		return nil
+	}
+
+	var pinned []perfv1.PinnedCPUs
+	if pinsCPUs(b) {
+		known := map[string]string{}
+		for _, p := range b.Status.PinnedCPUs {
+			known[p.Pod] = p.CPUs
+		}
+		pods := &corev1.PodList{}
+		err := r.List(ctx, pods, client.InNamespace(b.Namespace), client.MatchingLabels(labelsForBaseline(b.Name)))
+		if err != nil {
+			log.Error(err, "Failed to list the Baseline pods")
+			return err
+		}
+		limitBytes := int64(pinnedCPUsLogBytes)
+		for _, pod := range pods.Items {
+			if pod.Status.Phase != corev1.PodRunning {
+				continue
+			}
+			cpus, ok := known[pod.Name]
+			if !ok {
+				logs, err := r.KubeClient.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{LimitBytes: &limitBytes}).DoRaw(ctx)
+				if err != nil {
+					log.Error(err, "Failed to get the stress-ng logs", "Pod.Namespace", pod.Namespace, "Pod.Name", pod.Name)
+					continue
+				}
+				cpus = parsePinnedCPUs(logs)
+			}
+			if cpus != "" {
+				pinned = append(pinned, perfv1.PinnedCPUs{Node: pod.Spec.NodeName, Pod: pod.Name, CPUs: cpus})
+			}
+		}
+		sort.Slice(pinned, func(i, j int) bool { return pinned[i].Node < pinned[j].Node })
+	}
+
+	if equality.Semantic.DeepEqual(b.Status.PinnedCPUs, pinned) {
+		return nil
+	}
+	b.Status.PinnedCPUs = pinned
+	return r.updateStatus(ctx, b)
+}
@@ -0,0 +1,62 @@
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	perfv1 "github.com/josecastillolema/baseline-operator/api/v1"
+)
+
+var _ = Describe("CPU pinning", func() {
+
+	Context("Pinning the workers to a cpuset", func() {
+		It("Should pass the cpuset to stress-ng and report the cpus", func() {
+			cpu := int32(2)
+			b := &perfv1.Baseline{Spec: perfv1.BaselineSpec{Workload: perfv1.WorkloadStressNG, Cpu: &cpu, CPUSet: "2-3"}}
+			command, err := commandForBaseline(b)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(command).Should(Equal([]string{"stress-ng", "-t", "0", "--cpu", "2", "--taskset", "2-3"}))
+
+			r := &BaselineReconciler{Scheme: scheme.Scheme}
+			ds, err := r.daemonsetForBaseline(b, nil)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(ds.Spec.Template.Spec.Containers[0].Command[2]).Should(HavePrefix(`echo "PINNED_CPUS `))
+		})
+
+		It("Should parse the cpus logged by the pods", func() {
+			logs := []byte("PINNED_CPUS 2-3,6\nstress-ng: info:  [7] dispatching hogs: 2 cpu\n")
+			Expect(parsePinnedCPUs(logs)).Should(Equal("2-3,6"))
+			Expect(parsePinnedCPUs([]byte("stress-ng: info:  [7] dispatching hogs: 2 cpu\n"))).Should(BeEmpty())
+		})
+	})
+
+	Context("Requesting exclusive cpus", func() {
+		It("Should set the limits to the requests", func() {
+			guaranteed := int32(4)
+			b := &perfv1.Baseline{Spec: perfv1.BaselineSpec{
+				Workload:       perfv1.WorkloadStressNG,
+				GuaranteedCPUs: &guaranteed,
+				Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("1500m"),
+					corev1.ResourceMemory: resource.MustParse("1Gi"),
+				}},
+			}}
+			resources, err := resourcesForBaseline(b)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(resources.Requests).Should(Equal(resources.Limits))
+			Expect(resources.Limits.Cpu().Value()).Should(Equal(int64(4)))
+			Expect(resources.Limits[corev1.ResourceMemory]).Should(Equal(resource.MustParse("1Gi")))
+			Expect(pinsCPUs(b)).Should(BeTrue())
+		})
+
+		It("Should require a memory request", func() {
+			guaranteed := int32(2)
+			b := &perfv1.Baseline{Spec: perfv1.BaselineSpec{Workload: perfv1.WorkloadStressNG, GuaranteedCPUs: &guaranteed}}
+			_, err := resourcesForBaseline(b)
+			Expect(err).Should(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,162 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	perfv1 "github.com/josecastillolema/baseline-operator/api/v1"
+)
+
+// reconcileDeployments ensures the Deployments and Services of the workload
+// exist and are up to date, and deletes the ones of the Baseline no longer
+// desired, i.e. all of them when none is given
+func (r *BaselineReconciler) reconcileDeployments(ctx context.Context, b *perfv1.Baseline, desiredDeps []*appsv1.Deployment, desiredSvcs []*corev1.Service) error {
+	log := ctrllog.FromContext(ctx)
+	// Only the objects of the workloads running as Deployments are labelled
+	// with their workload
+	owned, _ := labels.NewRequirement("baseline_workload", selection.Exists, nil)
+	selector := client.MatchingLabelsSelector{Selector: labels.SelectorFromSet(labelsForBaseline(b.Name)).Add(*owned)}
+
+	deps := &appsv1.DeploymentList{}
+	err := r.List(ctx, deps, client.InNamespace(b.Namespace), selector)
+	if err != nil {
+		log.Error(err, "Failed to list the Deployments")
+		return err
+	}
+	found := map[string]*appsv1.Deployment{}
+	for i := range deps.Items {
+		found[deps.Items[i].Name] = &deps.Items[i]
+	}
+	for _, dep := range desiredDeps {
+		existing, ok := found[dep.Name]
+		delete(found, dep.Name)
+		if !ok {
+			log.Info("Creating a new Deployment", "Deployment.Namespace", dep.Namespace, "Deployment.Name", dep.Name)
+			err = r.Create(ctx, dep)
+			if err != nil {
+				log.Error(err, "Failed to create new Deployment", "Deployment.Namespace", dep.Namespace, "Deployment.Name", dep.Name)
+				return err
+			}
+			r.recorder.Event(b, "Normal", "Created", fmt.Sprintf("Created deployment %s/%s", dep.Namespace, dep.Name))
+			continue
+		}
+		if podSpecChanged(&existing.Spec.Template.Spec, &dep.Spec.Template.Spec) ||
+			!equality.Semantic.DeepEqual(existing.Spec.Template.Spec.Containers[0].Env, dep.Spec.Template.Spec.Containers[0].Env) ||
+			!equality.Semantic.DeepEqual(existing.Spec.Replicas, dep.Spec.Replicas) {
+			existing.Spec.Replicas = dep.Spec.Replicas
+			existing.Spec.Template.Spec = dep.Spec.Template.Spec
+			log.Info("Updating the Deployment", "Deployment.Namespace", existing.Namespace, "Deployment.Name", existing.Name)
+			err = r.Update(ctx, existing)
+			if err != nil {
+				log.Error(err, "Failed to update Deployment", "Deployment.Namespace", existing.Namespace, "Deployment.Name", existing.Name)
+				return err
+			}
+		}
+	}
+	for _, dep := range found {
+		log.Info("Deleting the Deployment", "Deployment.Namespace", dep.Namespace, "Deployment.Name", dep.Name)
+		err = r.Delete(ctx, dep, client.PropagationPolicy("Background"))
+		if err != nil && !errors.IsNotFound(err) {
+			log.Error(err, "Failed to delete Deployment", "Deployment.Namespace", dep.Namespace, "Deployment.Name", dep.Name)
+			return err
+		}
+	}
+
+	svcs := &corev1.ServiceList{}
+	err = r.List(ctx, svcs, client.InNamespace(b.Namespace), selector)
+	if err != nil {
+		log.Error(err, "Failed to list the Services")
+		return err
+	}
+	foundSvcs := map[string]*corev1.Service{}
+	for i := range svcs.Items {
+		foundSvcs[svcs.Items[i].Name] = &svcs.Items[i]
+	}
+	for _, svc := range desiredSvcs {
+		if _, ok := foundSvcs[svc.Name]; ok {
+			delete(foundSvcs, svc.Name)
+			continue
+		}
+		log.Info("Creating a new Service", "Service.Namespace", svc.Namespace, "Service.Name", svc.Name)
+		err = r.Create(ctx, svc)
+		if err != nil {
+			log.Error(err, "Failed to create new Service", "Service.Namespace", svc.Namespace, "Service.Name", svc.Name)
+			return err
+		}
+	}
+	for _, svc := range foundSvcs {
+		log.Info("Deleting the Service", "Service.Namespace", svc.Namespace, "Service.Name", svc.Name)
+		err = r.Delete(ctx, svc)
+		if err != nil && !errors.IsNotFound(err) {
+			log.Error(err, "Failed to delete Service", "Service.Namespace", svc.Namespace, "Service.Name", svc.Name)
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteDaemonSets deletes the daemonsets of a Baseline whose workload runs
+// as Deployments
+func (r *BaselineReconciler) deleteDaemonSets(ctx context.Context, b *perfv1.Baseline) error {
+	log := ctrllog.FromContext(ctx)
+	for _, name := range []string{b.Name, controlPlaneName(b)} {
+		found := &appsv1.DaemonSet{}
+		err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: b.Namespace}, found)
+		if errors.IsNotFound(err) {
+			continue
+		}
+		if err == nil {
+			log.Info("Deleting the DaemonSet replaced by Deployments", "DaemonSet.Namespace", found.Namespace, "DaemonSet.Name", found.Name)
+			err = r.Delete(ctx, found)
+		}
+		if err != nil && !errors.IsNotFound(err) {
+			log.Error(err, "Failed to delete DaemonSet", "DaemonSet.Namespace", b.Namespace, "DaemonSet.Name", name)
+			return err
+		}
+	}
+	return nil
+}
+
+// updateDeploymentsCommand records the command of the clients of a workload
+// running as Deployments in the Baseline status
+func (r *BaselineReconciler) updateDeploymentsCommand(ctx context.Context, b *perfv1.Baseline, args []string) error {
+	command := strings.Join(args, " ")
+	if b.Status.Command == command && b.Status.StartTime != nil {
+		return nil
+	}
+	b.Status.Command = command
+	b.Status.Custom = ""
+	if b.Status.StartTime == nil {
+		now := metav1.Now()
+		b.Status.StartTime = &now
+	}
+	return r.updateStatus(ctx, b)
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	perfv1 "github.com/josecastillolema/baseline-operator/api/v1"
+)
+
+// gracefulWrapper runs the stress-ng command given as arguments and turns
+// the SIGTERM sent to the pod into a SIGALRM, on which stress-ng stops its
+// workers and prints the final metrics as if its timeout expired. SIGINT can
+// not be used, as it is ignored by the background jobs of a shell
+const gracefulWrapper = `trap 'kill -ALRM "$pid" 2>/dev/null' TERM
+"$@" --metrics-brief &
+pid=$!
+wait "$pid"
+wait "$pid"`
+
+// gracefulCommand returns the container command wrapping stress-ng
+func gracefulCommand() []string {
+	return []string{"/bin/sh", "-c", gracefulWrapper, "stress-ng"}
+}
+
+// terminationGracePeriod returns how long the stress pods have to print
+// their final metrics once terminated
+func terminationGracePeriod(b *perfv1.Baseline) *int64 {
+	if b.Spec.TerminationGracePeriodSeconds != nil {
+		return b.Spec.TerminationGracePeriodSeconds
+	}
+	gracePeriod := int64(corev1.DefaultTerminationGracePeriodSeconds)
+	return &gracePeriod
+}
+
+// pdbForBaseline returns the PodDisruptionBudget of the Baseline pods
+func (r *BaselineReconciler) pdbForBaseline(b *perfv1.Baseline) *policyv1.PodDisruptionBudget {
+	minAvailable := intstr.FromInt(int(b.Spec.DisruptionBudget.MinAvailable))
+	pdb := &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      b.Name,
+			Namespace: b.Namespace,
+		},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			MinAvailable: &minAvailable,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: labelsForBaseline(b.Name),
+			},
+		},
+	}
+	// Set Baseline instance as the owner and controller
+	ctrl.SetControllerReference(b, pdb, r.Scheme)
+	return pdb
+}
+
+// reconcileDisruptionBudget ensures the PodDisruptionBudget exists only
+// while the Baseline asks for one
+func (r *BaselineReconciler) reconcileDisruptionBudget(ctx context.Context, b *perfv1.Baseline) error {
+	log := ctrllog.FromContext(ctx)
+
+	found := &policyv1.PodDisruptionBudget{}
+	err := r.Get(ctx, types.NamespacedName{Name: b.Name, Namespace: b.Namespace}, found)
+	if err != nil && !errors.IsNotFound(err) {
+		log.Error(err, "Failed to get PodDisruptionBudget")
+		return err
+	}
+	exists := err == nil
+
+	if b.Spec.DisruptionBudget == nil {
+		if !exists {
+			return nil
+		}
+		log.Info("Deleting the PodDisruptionBudget", "PodDisruptionBudget.Namespace", found.Namespace, "PodDisruptionBudget.Name", found.Name)
+		err = r.Delete(ctx, found)
+		if err != nil && !errors.IsNotFound(err) {
+			log.Error(err, "Failed to delete PodDisruptionBudget", "PodDisruptionBudget.Namespace", found.Namespace, "PodDisruptionBudget.Name", found.Name)
+			return err
+		}
+		return nil
+	}
+
+	pdb := r.pdbForBaseline(b)
+	if !exists {
+		log.Info("Creating a new PodDisruptionBudget", "PodDisruptionBudget.Namespace", pdb.Namespace, "PodDisruptionBudget.Name", pdb.Name)
+		err = r.Create(ctx, pdb)
+		if err != nil {
+			log.Error(err, "Failed to create new PodDisruptionBudget", "PodDisruptionBudget.Namespace", pdb.Namespace, "PodDisruptionBudget.Name", pdb.Name)
+			return err
+		}
+		r.recorder.Event(b, "Normal", "Created", fmt.Sprintf("Created poddisruptionbudget %s/%s", pdb.Namespace, pdb.Name))
+		return nil
+	}
+	if pdbSpecChanged(found, pdb) {
+		found.Spec.MinAvailable = pdb.Spec.MinAvailable
+		found.Spec.MaxUnavailable = pdb.Spec.MaxUnavailable
+		found.Spec.Selector = pdb.Spec.Selector
+		log.Info("Updating the PodDisruptionBudget", "PodDisruptionBudget.Namespace", found.Namespace, "PodDisruptionBudget.Name", found.Name)
+		err = r.Update(ctx, found)
+		if err != nil {
+			log.Error(err, "Failed to update PodDisruptionBudget", "PodDisruptionBudget.Namespace", found.Namespace, "PodDisruptionBudget.Name", found.Name)
+			return err
+		}
+	}
+	return nil
+}
+
+// pdbSpecChanged returns if the found PodDisruptionBudget differs from the
+// desired one, i.e. it was edited to use maxUnavailable
+func pdbSpecChanged(found, desired *policyv1.PodDisruptionBudget) bool {
+	return !equality.Semantic.DeepEqual(found.Spec.MinAvailable, desired.Spec.MinAvailable) ||
+		!equality.Semantic.DeepEqual(found.Spec.MaxUnavailable, desired.Spec.MaxUnavailable) ||
+		!equality.Semantic.DeepEqual(found.Spec.Selector, desired.Spec.Selector)
+}
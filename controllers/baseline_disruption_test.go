@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/envtest/komega"
+
+	perfv1 "github.com/josecastillolema/baseline-operator/api/v1"
+)
+
+var _ = Describe("Disruptions", func() {
+
+	const (
+		BaselineName      = "test-disruption-baseline"
+		BaselineNamespace = "default"
+	)
+
+	Context("Comparing the disruption budgets", func() {
+		It("Should detect a budget edited to use maxUnavailable", func() {
+			r := &BaselineReconciler{Scheme: scheme.Scheme}
+			b := &perfv1.Baseline{Spec: perfv1.BaselineSpec{DisruptionBudget: &perfv1.DisruptionBudget{MinAvailable: 1}}}
+			b.Name = BaselineName
+			b.Namespace = BaselineNamespace
+			desired := r.pdbForBaseline(b)
+			found := desired.DeepCopy()
+			Expect(pdbSpecChanged(found, desired)).Should(BeFalse())
+
+			maxUnavailable := intstr.FromInt(1)
+			found.Spec.MinAvailable = nil
+			found.Spec.MaxUnavailable = &maxUnavailable
+			Expect(pdbSpecChanged(found, desired)).Should(BeTrue())
+		})
+	})
+
+	Context("Terminating the stress pods", func() {
+		It("Should wrap stress-ng to print the final metrics", func() {
+			By("By creating a Baseline with a disruption budget")
+			ctx := context.Background()
+			cpu := int32(1)
+			gracePeriod := int64(10)
+			baseline := &perfv1.Baseline{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      BaselineName,
+					Namespace: BaselineNamespace,
+				},
+				Spec: perfv1.BaselineSpec{
+					Cpu:                           &cpu,
+					TerminationGracePeriodSeconds: &gracePeriod,
+					DisruptionBudget:              &perfv1.DisruptionBudget{MinAvailable: 1},
+				},
+			}
+			Expect(k8sClient.Create(ctx, baseline)).Should(Succeed())
+
+			ds := &appsv1.DaemonSet{ObjectMeta: metav1.ObjectMeta{Name: BaselineName, Namespace: BaselineNamespace}}
+			Eventually(komega.Object(ds)).Should(HaveField("Spec.Template.Spec.Containers", ContainElement(SatisfyAll(
+				HaveField("Command", Equal(gracefulCommand())),
+				HaveField("Args", Equal([]string{"stress-ng", "-t", "0", "--cpu", "1"})),
+			))))
+			Expect(*ds.Spec.Template.Spec.TerminationGracePeriodSeconds).Should(Equal(int64(10)))
+
+			pdb := &policyv1.PodDisruptionBudget{ObjectMeta: metav1.ObjectMeta{Name: BaselineName, Namespace: BaselineNamespace}}
+			Eventually(komega.Object(pdb)).Should(HaveField("Spec.MinAvailable", Equal(&intstr.IntOrString{IntVal: 1})))
+
+			By("By removing the disruption budget")
+			Eventually(komega.Update(baseline, func() {
+				baseline.Spec.DisruptionBudget = nil
+			})).Should(Succeed())
+			Eventually(func() bool {
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: BaselineName, Namespace: BaselineNamespace}, &policyv1.PodDisruptionBudget{})
+				return errors.IsNotFound(err)
+			}).Should(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,131 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	perfv1 "github.com/josecastillolema/baseline-operator/api/v1"
+)
+
+const (
+	// baselineFinalizer holds a deleted Baseline until its workload stops
+	baselineFinalizer = "perf.baseline.io/finalizer"
+	// defaultStopTimeout is how long the pods of a deleted Baseline are
+	// waited for when no timeout is configured
+	defaultStopTimeout = 2 * time.Minute
+	// stopPollInterval is how often the pods of a deleted Baseline are checked
+	stopPollInterval = 2 * time.Second
+)
+
+// finalize scales down the workload of a deleted Baseline, waits for its
+// pods to terminate and releases the Baseline
+func (r *BaselineReconciler) finalize(ctx context.Context, b *perfv1.Baseline) (ctrl.Result, error) {
+	log := ctrllog.FromContext(ctx)
+
+	if !controllerutil.ContainsFinalizer(b, baselineFinalizer) {
+		return ctrl.Result{}, nil
+	}
+
+	running, err := r.stopWorkload(ctx, b)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if running > 0 {
+		if time.Since(b.DeletionTimestamp.Time) < r.stopTimeout() {
+			log.Info("Waiting for the Baseline pods to terminate", "Pods", running)
+			return ctrl.Result{RequeueAfter: stopPollInterval}, nil
+		}
+		r.recorder.Event(b, "Warning", "StopTimeout", fmt.Sprintf("%d pods still running after %s", running, r.stopTimeout()))
+	}
+
+	start := b.CreationTimestamp.Time
+	if b.Status.StartTime != nil {
+		start = b.Status.StartTime.Time
+	}
+	r.recorder.Event(b, "Normal", "Stopped", fmt.Sprintf("Stopped after running for %s", time.Since(start).Round(time.Second)))
+
+	setFioMetrics(b, nil)
+	setIperf3Metrics(b, nil)
+	churnLatency.DeleteLabelValues(b.Namespace, b.Name)
+	controllerutil.RemoveFinalizer(b, baselineFinalizer)
+	err = r.Update(ctx, b)
+	if err != nil {
+		log.Error(err, "Failed to remove the Baseline finalizer")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// stopWorkload deletes the daemonset of the Baseline and returns how many of
+// its pods are still running
+func (r *BaselineReconciler) stopWorkload(ctx context.Context, b *perfv1.Baseline) (int, error) {
+	log := ctrllog.FromContext(ctx)
+
+	for _, name := range []string{b.Name, controlPlaneName(b)} {
+		found := &appsv1.DaemonSet{}
+		err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: b.Namespace}, found)
+		if err == nil && found.DeletionTimestamp.IsZero() {
+			log.Info("Deleting the DaemonSet of the stopped Baseline", "DaemonSet.Namespace", found.Namespace, "DaemonSet.Name", found.Name)
+			err = r.Delete(ctx, found, client.PropagationPolicy("Background"))
+		}
+		if err != nil && !errors.IsNotFound(err) {
+			log.Error(err, "Failed to delete DaemonSet", "DaemonSet.Namespace", b.Namespace, "DaemonSet.Name", name)
+			return 0, err
+		}
+	}
+
+	err := r.reconcileDeployments(ctx, b, nil, nil)
+	if err != nil {
+		return 0, err
+	}
+	err = r.deleteSandboxes(ctx, b, "")
+	if err != nil {
+		return 0, err
+	}
+	err = r.deleteChurnPods(ctx, b)
+	if err != nil {
+		return 0, err
+	}
+
+	pods := &corev1.PodList{}
+	err = r.List(ctx, pods, client.InNamespace(b.Namespace), client.MatchingLabels(labelsForBaseline(b.Name)))
+	if err != nil {
+		log.Error(err, "Failed to list the Baseline pods")
+		return 0, err
+	}
+	return len(pods.Items), nil
+}
+
+// stopTimeout returns how long the pods of a deleted Baseline are waited for
+func (r *BaselineReconciler) stopTimeout() time.Duration {
+	if r.StopTimeout == 0 {
+		return defaultStopTimeout
+	}
+	return r.StopTimeout
+}
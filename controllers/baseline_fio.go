@@ -0,0 +1,314 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	perfv1 "github.com/josecastillolema/baseline-operator/api/v1"
+)
+
+const (
+	// fioJobVolumeName is the name of the volume holding the fio job
+	fioJobVolumeName = "fio-job"
+	// fioJobPath is where the fio job is mounted
+	fioJobPath = "/etc/fio"
+	// fioJobFile is the key of the fio job in its ConfigMap
+	fioJobFile = "job.fio"
+	// fioResultPrefix prefixes the log line holding the results of a run
+	fioResultPrefix = "FIO_RESULT "
+	// fioLogLines is how many log lines are searched for the last results
+	fioLogLines = 10
+	// defaultFioRuntime is how long each fio run lasts when not set
+	defaultFioRuntime = time.Minute
+)
+
+// fioWrapper runs the fio command given as arguments in a loop, logging the
+// results of each run in a single line. A failed run, i.e. on an invalid job
+// or a volume not supporting direct I/O, is retried after a delay
+const fioWrapper = `trap 'kill "$pid" 2>/dev/null; exit 0' TERM
+while true; do
+  "$@" &
+  pid=$!
+  if wait "$pid"; then
+    echo "` + fioResultPrefix + `$(tr -d '\n' < /tmp/result.json)"
+  else
+    sleep 5
+  fi
+done`
+
+// fioCommand returns the container command and arguments of the Fio workload
+func fioCommand() ([]string, []string) {
+	return []string{"/bin/sh", "-c", fioWrapper, "fio"},
+		[]string{"fio", "--output-format=json", "--output=/tmp/result.json", fioJobPath + "/" + fioJobFile}
+}
+
+// fioSpec returns the fio job of the Baseline with its defaults
+func fioSpec(b *perfv1.Baseline) perfv1.FioWorkload {
+	fio := perfv1.FioWorkload{}
+	if b.Spec.Fio != nil {
+		fio = *b.Spec.Fio.DeepCopy()
+	}
+	if fio.ReadWrite == "" {
+		fio.ReadWrite = "randrw"
+	}
+	if fio.RwMixRead == nil {
+		mix := int32(50)
+		fio.RwMixRead = &mix
+	}
+	if fio.BlockSize == "" {
+		fio.BlockSize = "4k"
+	}
+	if fio.IoDepth == 0 {
+		fio.IoDepth = 16
+	}
+	if fio.Size == "" {
+		fio.Size = "1G"
+	}
+	if fio.NumJobs == 0 {
+		fio.NumJobs = 1
+	}
+	if fio.Runtime == nil {
+		fio.Runtime = &metav1.Duration{Duration: defaultFioRuntime}
+	}
+	return fio
+}
+
+// fioJob renders the fio job file of the Baseline
+func fioJob(b *perfv1.Baseline) string {
+	fio := fioSpec(b)
+	lines := []string{
+		"[global]",
+		"ioengine=libaio",
+		"direct=1",
+		"time_based=1",
+		fmt.Sprintf("runtime=%d", int64(fio.Runtime.Seconds())),
+		"directory=" + scratchPath,
+		"group_reporting=1",
+		"",
+		"[baseline]",
+		"rw=" + fio.ReadWrite,
+		fmt.Sprintf("rwmixread=%d", *fio.RwMixRead),
+		"bs=" + fio.BlockSize,
+		fmt.Sprintf("iodepth=%d", fio.IoDepth),
+		"size=" + fio.Size,
+		fmt.Sprintf("numjobs=%d", fio.NumJobs),
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// fioConfigMapName returns the name of the ConfigMap holding the fio job
+func fioConfigMapName(b *perfv1.Baseline) string {
+	return b.Name + "-fio"
+}
+
+// fioJobVolume returns the volume and the mount of the fio job
+func fioJobVolume(b *perfv1.Baseline) (corev1.Volume, corev1.VolumeMount) {
+	volume := corev1.Volume{
+		Name: fioJobVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: fioConfigMapName(b)},
+			},
+		},
+	}
+	return volume, corev1.VolumeMount{Name: fioJobVolumeName, MountPath: fioJobPath, ReadOnly: true}
+}
+
+// reconcileFioJob ensures the ConfigMap holding the fio job exists only
+// while the Baseline runs the Fio workload. Changes of the job are picked up
+// by the next run
+func (r *BaselineReconciler) reconcileFioJob(ctx context.Context, b *perfv1.Baseline) error {
+	log := ctrllog.FromContext(ctx)
+
+	found := &corev1.ConfigMap{}
+	err := r.Get(ctx, types.NamespacedName{Name: fioConfigMapName(b), Namespace: b.Namespace}, found)
+	if err != nil && !errors.IsNotFound(err) {
+		log.Error(err, "Failed to get ConfigMap")
+		return err
+	}
+	exists := err == nil
+
+	if b.Spec.Workload != perfv1.WorkloadFio {
+		if exists {
+			log.Info("Deleting the fio job ConfigMap", "ConfigMap.Namespace", found.Namespace, "ConfigMap.Name", found.Name)
+			err = r.Delete(ctx, found)
+			if err != nil && !errors.IsNotFound(err) {
+				log.Error(err, "Failed to delete ConfigMap", "ConfigMap.Namespace", found.Namespace, "ConfigMap.Name", found.Name)
+				return err
+			}
+		}
+		return nil
+	}
+
+	data := map[string]string{fioJobFile: fioJob(b)}
+	if !exists {
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fioConfigMapName(b),
+				Namespace: b.Namespace,
+				Labels:    labelsForBaseline(b.Name),
+			},
+			Data: data,
+		}
+		// Set Baseline instance as the owner and controller
+		ctrl.SetControllerReference(b, cm, r.Scheme)
+		log.Info("Creating a new fio job ConfigMap", "ConfigMap.Namespace", cm.Namespace, "ConfigMap.Name", cm.Name)
+		err = r.Create(ctx, cm)
+		if err != nil {
+			log.Error(err, "Failed to create new ConfigMap", "ConfigMap.Namespace", cm.Namespace, "ConfigMap.Name", cm.Name)
+		}
+		return err
+	}
+	if !reflect.DeepEqual(found.Data, data) {
+		found.Data = data
+		log.Info("Updating the fio job ConfigMap", "ConfigMap.Namespace", found.Namespace, "ConfigMap.Name", found.Name)
+		err = r.Update(ctx, found)
+		if err != nil {
+			log.Error(err, "Failed to update ConfigMap", "ConfigMap.Namespace", found.Namespace, "ConfigMap.Name", found.Name)
+		}
+		return err
+	}
+	return nil
+}
+
+// fioOutput is the part of the fio json output the results are taken from
+type fioOutput struct {
+	Timestamp int64 `json:"timestamp"`
+	Jobs      []struct {
+		Read  fioStats `json:"read"`
+		Write fioStats `json:"write"`
+	} `json:"jobs"`
+}
+
+// fioStats are the statistics of one direction of an fio job
+type fioStats struct {
+	IOPS  float64 `json:"iops"`
+	LatNs struct {
+		Mean float64 `json:"mean"`
+	} `json:"lat_ns"`
+}
+
+// parseFioResult returns the results of the last run logged by a fio pod
+func parseFioResult(logs []byte, node string) (*perfv1.FioResult, error) {
+	var last string
+	scanner := bufio.NewScanner(bytes.NewReader(logs))
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		if line := scanner.Text(); strings.HasPrefix(line, fioResultPrefix) {
+			last = strings.TrimPrefix(line, fioResultPrefix)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if last == "" {
+		return nil, nil
+	}
+
+	output := fioOutput{}
+	if err := json.Unmarshal([]byte(last), &output); err != nil {
+		return nil, err
+	}
+	result := &perfv1.FioResult{Node: node, Time: metav1.NewTime(time.Unix(output.Timestamp, 0))}
+	var readLat, writeLat float64
+	for _, job := range output.Jobs {
+		result.ReadIOPS += int64(job.Read.IOPS)
+		result.WriteIOPS += int64(job.Write.IOPS)
+		readLat += job.Read.LatNs.Mean * job.Read.IOPS
+		writeLat += job.Write.LatNs.Mean * job.Write.IOPS
+	}
+	// The latencies of the jobs are weighted by their operations
+	if result.ReadIOPS > 0 {
+		result.ReadLatency.Duration = time.Duration(readLat / float64(result.ReadIOPS))
+	}
+	if result.WriteIOPS > 0 {
+		result.WriteLatency.Duration = time.Duration(writeLat / float64(result.WriteIOPS))
+	}
+	return result, nil
+}
+
+// collectFioResults records in the status and the metrics the results of
+// the last fio run of every pod of the Baseline
+func (r *BaselineReconciler) collectFioResults(ctx context.Context, b *perfv1.Baseline) error {
+	log := ctrllog.FromContext(ctx)
+	if r.KubeClient == nil {
+		return nil
+	}
+
+	var results []perfv1.FioResult
+	if b.Spec.Workload == perfv1.WorkloadFio {
+		pods := &corev1.PodList{}
+		err := r.List(ctx, pods, client.InNamespace(b.Namespace), client.MatchingLabels(labelsForBaseline(b.Name)))
+		if err != nil {
+			log.Error(err, "Failed to list the Baseline pods")
+			return err
+		}
+		tailLines := int64(fioLogLines)
+		for _, pod := range pods.Items {
+			if pod.Status.Phase != corev1.PodRunning {
+				continue
+			}
+			logs, err := r.KubeClient.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{TailLines: &tailLines}).DoRaw(ctx)
+			if err != nil {
+				log.Error(err, "Failed to get the fio logs", "Pod.Namespace", pod.Namespace, "Pod.Name", pod.Name)
+				continue
+			}
+			result, err := parseFioResult(logs, pod.Spec.NodeName)
+			if err != nil {
+				log.Error(err, "Failed to parse the fio results", "Pod.Namespace", pod.Namespace, "Pod.Name", pod.Name)
+				continue
+			}
+			if result != nil {
+				results = append(results, *result)
+			}
+		}
+		sort.Slice(results, func(i, j int) bool { return results[i].Node < results[j].Node })
+	}
+
+	setFioMetrics(b, results)
+	if equality.Semantic.DeepEqual(b.Status.FioResults, results) {
+		return nil
+	}
+	b.Status.FioResults = results
+	return r.updateStatus(ctx, b)
+}
+
+// fioRequeue returns when the next fio results are expected
+func fioRequeue(b *perfv1.Baseline) time.Duration {
+	if b.Spec.Workload != perfv1.WorkloadFio {
+		return 0
+	}
+	return fioSpec(b).Runtime.Duration
+}
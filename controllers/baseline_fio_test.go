@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/envtest/komega"
+
+	perfv1 "github.com/josecastillolema/baseline-operator/api/v1"
+)
+
+var _ = Describe("Fio workload", func() {
+
+	const (
+		BaselineName      = "test-fio-baseline"
+		BaselineNamespace = "default"
+	)
+
+	Context("Rendering the fio job", func() {
+		It("Should fill the defaults and write to the scratch volume", func() {
+			b := &perfv1.Baseline{Spec: perfv1.BaselineSpec{
+				Workload: perfv1.WorkloadFio,
+				Fio:      &perfv1.FioWorkload{ReadWrite: "randread", BlockSize: "64k"},
+			}}
+			job := fioJob(b)
+			Expect(job).Should(ContainSubstring("directory=/scratch\n"))
+			Expect(job).Should(ContainSubstring("rw=randread\n"))
+			Expect(job).Should(ContainSubstring("bs=64k\n"))
+			Expect(job).Should(ContainSubstring("iodepth=16\n"))
+			Expect(job).Should(ContainSubstring("runtime=60\n"))
+		})
+	})
+
+	Context("Parsing the fio logs", func() {
+		It("Should take the results of the last run", func() {
+			logs := []byte(`FIO_RESULT {"timestamp": 1000, "jobs": [{"read": {"iops": 10, "lat_ns": {"mean": 1000}}, "write": {"iops": 5, "lat_ns": {"mean": 2000}}}]}
+FIO_RESULT {"timestamp": 1060, "jobs": [{"read": {"iops": 200.5, "lat_ns": {"mean": 500000}}, "write": {"iops": 100, "lat_ns": {"mean": 1000000}}}]}
+`)
+			result, err := parseFioResult(logs, "node-1")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(result.Node).Should(Equal("node-1"))
+			Expect(result.ReadIOPS).Should(Equal(int64(200)))
+			Expect(result.WriteIOPS).Should(Equal(int64(100)))
+			Expect(result.WriteLatency.Duration).Should(Equal(time.Millisecond))
+			Expect(result.Time.Unix()).Should(Equal(int64(1060)))
+
+			result, err = parseFioResult([]byte("starting\n"), "node-1")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(result).Should(BeNil())
+		})
+	})
+
+	Context("Running the Fio workload", func() {
+		It("Should mount the fio job and the scratch volume", func() {
+			ctx := context.Background()
+			baseline := &perfv1.Baseline{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      BaselineName,
+					Namespace: BaselineNamespace,
+				},
+				Spec: perfv1.BaselineSpec{
+					Workload: perfv1.WorkloadFio,
+					Fio:      &perfv1.FioWorkload{IoDepth: 32},
+				},
+			}
+			Expect(k8sClient.Create(ctx, baseline)).Should(Succeed())
+
+			cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: BaselineName + "-fio", Namespace: BaselineNamespace}}
+			Eventually(komega.Object(cm)).Should(HaveField("Data", HaveKeyWithValue("job.fio", ContainSubstring("iodepth=32\n"))))
+
+			ds := &appsv1.DaemonSet{ObjectMeta: metav1.ObjectMeta{Name: BaselineName, Namespace: BaselineNamespace}}
+			Eventually(komega.Object(ds)).Should(HaveField("Spec.Template.Spec.Volumes", HaveLen(2)))
+			Expect(ds.Spec.Template.Spec.Containers[0].Image).Should(Equal(perfv1.DefaultFioImage))
+		})
+	})
+})
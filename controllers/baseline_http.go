@@ -0,0 +1,216 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	perfv1 "github.com/josecastillolema/baseline-operator/api/v1"
+)
+
+const (
+	// httpPort is the port the echo servers listen on
+	httpPort = 8080
+	// httpMetricsPort is the port the load generators expose their metrics on
+	httpMetricsPort = 9090
+	// httpbenchPath is the path of the httpbench binary in the operator image
+	httpbenchPath = "/httpbench"
+)
+
+// httpSpec returns the traffic of the Baseline with its defaults
+func httpSpec(b *perfv1.Baseline) perfv1.HTTPWorkload {
+	http := perfv1.HTTPWorkload{}
+	if b.Spec.HTTP != nil {
+		http = *b.Spec.HTTP.DeepCopy()
+	}
+	if http.Servers == 0 {
+		http.Servers = 1
+	}
+	if http.Clients == 0 {
+		http.Clients = 1
+	}
+	if http.RPS == 0 {
+		http.RPS = 100
+	}
+	if http.PayloadSize == nil {
+		payloadSize := int32(1024)
+		http.PayloadSize = &payloadSize
+	}
+	if http.Concurrency == 0 {
+		http.Concurrency = 10
+	}
+	return http
+}
+
+// httpServerName returns the name of the echo server Deployment and Service
+func httpServerName(b *perfv1.Baseline) string {
+	return b.Name + "-http-server"
+}
+
+// httpClientName returns the name of the load generator Deployment
+func httpClientName(b *perfv1.Baseline) string {
+	return b.Name + "-http-client"
+}
+
+// httpMetricsName returns the name of the Service exposing the metrics of
+// the load generators
+func httpMetricsName(b *perfv1.Baseline) string {
+	return b.Name + "-http-metrics"
+}
+
+// labelsForHTTP returns the labels of the HTTP objects of a role
+func labelsForHTTP(name string, role string) map[string]string {
+	ls := labelsForBaseline(name)
+	ls["baseline_workload"] = "http"
+	ls["baseline_role"] = role
+	return ls
+}
+
+// httpLoadArgs returns the arguments of the load generators
+func httpLoadArgs(b *perfv1.Baseline) []string {
+	http := httpSpec(b)
+	return []string{"load",
+		"--url", fmt.Sprintf("http://%s:%d/", httpServerName(b), httpPort),
+		"--rps", strconv.Itoa(int(http.RPS)),
+		"--payload-size", strconv.Itoa(int(*http.PayloadSize)),
+		"--concurrency", strconv.Itoa(int(http.Concurrency)),
+		"--metrics-bind-address", fmt.Sprintf(":%d", httpMetricsPort)}
+}
+
+// httpDeployment returns the Deployment of the echo servers or of the load
+// generators
+func (r *BaselineReconciler) httpDeployment(b *perfv1.Baseline, role string, affinity *corev1.Affinity) *appsv1.Deployment {
+	http := httpSpec(b)
+	ls := labelsForHTTP(b.Name, role)
+	name := httpServerName(b)
+	replicas := http.Servers
+	container := corev1.Container{
+		Name:            "echo-server",
+		Image:           b.Spec.Image,
+		ImagePullPolicy: b.Spec.ImagePullPolicy,
+		Command:         []string{httpbenchPath},
+		Args:            []string{"server", "--bind-address", fmt.Sprintf(":%d", httpPort)},
+		Resources:       b.Spec.Resources,
+		Ports:           []corev1.ContainerPort{{Name: "http", ContainerPort: httpPort, Protocol: corev1.ProtocolTCP}},
+		ReadinessProbe: &corev1.Probe{
+			ProbeHandler: corev1.ProbeHandler{
+				HTTPGet: &corev1.HTTPGetAction{Path: "/healthz", Port: intstr.FromString("http")},
+			},
+		},
+	}
+	if role == "client" {
+		name = httpClientName(b)
+		replicas = http.Clients
+		container.Name = "load-generator"
+		container.Args = httpLoadArgs(b)
+		container.Ports = []corev1.ContainerPort{{Name: "metrics", ContainerPort: httpMetricsPort, Protocol: corev1.ProtocolTCP}}
+		container.ReadinessProbe = nil
+	}
+
+	dep := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: b.Namespace,
+			Labels:    ls,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: ls,
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: ls,
+				},
+				Spec: corev1.PodSpec{
+					NodeSelector:                  b.Spec.NodeSelector,
+					Affinity:                      affinity,
+					Tolerations:                   b.Spec.Tolerations,
+					TerminationGracePeriodSeconds: terminationGracePeriod(b),
+					ImagePullSecrets:              b.Spec.ImagePullSecrets,
+					Containers:                    []corev1.Container{container},
+				},
+			},
+		},
+	}
+	// Set Baseline instance as the owner and controller
+	ctrl.SetControllerReference(b, dep, r.Scheme)
+	return dep
+}
+
+// httpService returns the Service of the echo servers or the one exposing
+// the metrics of the load generators
+func (r *BaselineReconciler) httpService(b *perfv1.Baseline, role string) *corev1.Service {
+	ls := labelsForHTTP(b.Name, role)
+	name := httpServerName(b)
+	port := corev1.ServicePort{Name: "http", Port: httpPort, TargetPort: intstr.FromString("http"), Protocol: corev1.ProtocolTCP}
+	if role == "client" {
+		name = httpMetricsName(b)
+		port = corev1.ServicePort{Name: "metrics", Port: httpMetricsPort, TargetPort: intstr.FromString("metrics"), Protocol: corev1.ProtocolTCP}
+	}
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: b.Namespace,
+			Labels:    ls,
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: ls,
+			Ports:    []corev1.ServicePort{port},
+		},
+	}
+	// Set Baseline instance as the owner and controller
+	ctrl.SetControllerReference(b, svc, r.Scheme)
+	return svc
+}
+
+// runHTTP runs the echo servers behind their Service and the load
+// generators sending them traffic
+func (r *BaselineReconciler) runHTTP(ctx context.Context, b *perfv1.Baseline, affinity *corev1.Affinity) (ctrl.Result, error) {
+	log := ctrllog.FromContext(ctx)
+
+	err := r.deleteDaemonSets(ctx, b)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	deps := []*appsv1.Deployment{r.httpDeployment(b, "server", affinity), r.httpDeployment(b, "client", affinity)}
+	svcs := []*corev1.Service{r.httpService(b, "server"), r.httpService(b, "client")}
+	err = r.reconcileDeployments(ctx, b, deps, svcs)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	err = r.reconcileDisruptionBudget(ctx, b)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	err = r.updateDeploymentsCommand(ctx, b, httpLoadArgs(b))
+	if err != nil {
+		log.Error(err, "Failed to update Baseline status")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
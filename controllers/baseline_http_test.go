@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/envtest/komega"
+
+	perfv1 "github.com/josecastillolema/baseline-operator/api/v1"
+)
+
+var _ = Describe("HTTP workload", func() {
+
+	const (
+		BaselineName      = "test-http-baseline"
+		BaselineNamespace = "default"
+	)
+
+	Context("Rendering the load generator arguments", func() {
+		It("Should fill the defaults and target the server Service", func() {
+			b := &perfv1.Baseline{
+				ObjectMeta: metav1.ObjectMeta{Name: "web"},
+				Spec: perfv1.BaselineSpec{
+					Workload: perfv1.WorkloadHTTP,
+					HTTP:     &perfv1.HTTPWorkload{RPS: 500},
+				},
+			}
+			Expect(httpLoadArgs(b)).Should(Equal([]string{"load",
+				"--url", "http://web-http-server:8080/",
+				"--rps", "500",
+				"--payload-size", "1024",
+				"--concurrency", "10",
+				"--metrics-bind-address", ":9090"}))
+		})
+	})
+
+	Context("Running the HTTP workload", func() {
+		It("Should deploy the echo servers behind a Service and the load generators", func() {
+			ctx := context.Background()
+			baseline := &perfv1.Baseline{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      BaselineName,
+					Namespace: BaselineNamespace,
+				},
+				Spec: perfv1.BaselineSpec{
+					Workload: perfv1.WorkloadHTTP,
+					HTTP:     &perfv1.HTTPWorkload{Servers: 2, Clients: 3},
+				},
+			}
+			Expect(k8sClient.Create(ctx, baseline)).Should(Succeed())
+
+			server := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: BaselineName + "-http-server", Namespace: BaselineNamespace}}
+			Eventually(komega.Object(server)).Should(HaveField("Spec.Replicas", HaveValue(Equal(int32(2)))))
+			Expect(server.Spec.Template.Spec.Containers[0].Image).Should(Equal(perfv1.DefaultToolsImage))
+
+			client := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: BaselineName + "-http-client", Namespace: BaselineNamespace}}
+			Eventually(komega.Object(client)).Should(HaveField("Spec.Replicas", HaveValue(Equal(int32(3)))))
+
+			svc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: BaselineName + "-http-server", Namespace: BaselineNamespace}}
+			Eventually(komega.Object(svc)).Should(HaveField("Spec.Ports", HaveLen(1)))
+
+			By("Scaling the load generators in place")
+			Expect(komega.Update(baseline, func() {
+				baseline.Spec.HTTP.Clients = 1
+			})()).Should(Succeed())
+			Eventually(komega.Object(client)).Should(HaveField("Spec.Replicas", HaveValue(Equal(int32(1)))))
+		})
+	})
+})
@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	perfv1 "github.com/josecastillolema/baseline-operator/api/v1"
+)
+
+var _ = Describe("Image pull settings", func() {
+
+	newBaseline := func() *perfv1.Baseline {
+		cpu := int32(1)
+		b := &perfv1.Baseline{Spec: perfv1.BaselineSpec{
+			Cpu:              &cpu,
+			Image:            "registry.local/stressng:latest",
+			ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry-credentials"}},
+			ImagePullPolicy:  corev1.PullAlways,
+		}}
+		b.Name = "test-baseline"
+		b.Namespace = "default"
+		return b
+	}
+
+	Context("Rendering the pods", func() {
+		It("Should apply the pull secrets and policy to every workload", func() {
+			b := newBaseline()
+			r := &BaselineReconciler{Scheme: scheme.Scheme}
+			ds, err := r.daemonsetForBaseline(b, nil)
+			Expect(err).ShouldNot(HaveOccurred())
+			specs := []corev1.PodSpec{ds.Spec.Template.Spec, r.churnPod(b, nil).Spec,
+				r.httpDeployment(b, "server", nil).Spec.Template.Spec, apiLoadDeployment(b, nil).Spec.Template.Spec}
+			for _, spec := range specs {
+				Expect(spec.ImagePullSecrets).Should(Equal(b.Spec.ImagePullSecrets))
+				Expect(spec.Containers[0].ImagePullPolicy).Should(Equal(corev1.PullAlways))
+			}
+		})
+	})
+
+	Context("Updating the pods", func() {
+		It("Should only compare the pull policy when it is set", func() {
+			b := newBaseline()
+			r := &BaselineReconciler{Scheme: scheme.Scheme}
+			ds, err := r.daemonsetForBaseline(b, nil)
+			Expect(err).ShouldNot(HaveOccurred())
+			found := ds.DeepCopy()
+			Expect(podSpecChanged(&found.Spec.Template.Spec, &ds.Spec.Template.Spec)).Should(BeFalse())
+
+			found.Spec.Template.Spec.ImagePullSecrets = nil
+			Expect(podSpecChanged(&found.Spec.Template.Spec, &ds.Spec.Template.Spec)).Should(BeTrue())
+
+			// The API server defaults the policy of the found pods
+			found = ds.DeepCopy()
+			ds.Spec.Template.Spec.Containers[0].ImagePullPolicy = ""
+			found.Spec.Template.Spec.Containers[0].ImagePullPolicy = corev1.PullIfNotPresent
+			Expect(podSpecChanged(&found.Spec.Template.Spec, &ds.Spec.Template.Spec)).Should(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,493 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	perfv1 "github.com/josecastillolema/baseline-operator/api/v1"
+)
+
+const (
+	// iperf3Port is the port the iperf3 servers listen on
+	iperf3Port = 5201
+	// iperf3ResultPrefix prefixes the log line holding the results of a test
+	iperf3ResultPrefix = "IPERF3_RESULT "
+	// iperf3LogLines is how many log lines are searched for the last results
+	iperf3LogLines = 10
+	// defaultIperf3Duration is how long each iperf3 test lasts when not set
+	defaultIperf3Duration = time.Minute
+)
+
+// iperf3Wrapper runs the iperf3 client command given as arguments in a
+// loop, logging the results of each test in a single line
+const iperf3Wrapper = `trap 'kill "$pid" 2>/dev/null; exit 0' TERM
+while true; do
+  "$@" > /tmp/result.json &
+  pid=$!
+  if wait "$pid"; then
+    echo "` + iperf3ResultPrefix + `$(tr -d '\n' < /tmp/result.json)"
+  else
+    sleep 5
+  fi
+done`
+
+// iperf3Pair is an iperf3 test from the client on the source node to the
+// server on the destination node
+type iperf3Pair struct {
+	Source      string
+	Destination string
+}
+
+// id returns a short identifier of the pair, stable across node changes
+func (p iperf3Pair) id() string {
+	h := fnv.New32a()
+	h.Write([]byte(p.Source + "/" + p.Destination))
+	return fmt.Sprintf("%08x", h.Sum32())
+}
+
+// iperf3Spec returns the iperf3 tests of the Baseline with their defaults
+func iperf3Spec(b *perfv1.Baseline) perfv1.Iperf3Workload {
+	iperf3 := perfv1.Iperf3Workload{}
+	if b.Spec.Iperf3 != nil {
+		iperf3 = *b.Spec.Iperf3.DeepCopy()
+	}
+	if iperf3.Topology == "" {
+		iperf3.Topology = perfv1.TopologyRing
+	}
+	if iperf3.Protocol == "" {
+		iperf3.Protocol = "TCP"
+	}
+	if iperf3.Parallel == 0 {
+		iperf3.Parallel = 1
+	}
+	if iperf3.Duration == nil {
+		iperf3.Duration = &metav1.Duration{Duration: defaultIperf3Duration}
+	}
+	return iperf3
+}
+
+// iperf3Pairs pairs the sorted nodes according to the topology
+func iperf3Pairs(topology perfv1.Iperf3Topology, nodes []string) []iperf3Pair {
+	var pairs []iperf3Pair
+	if len(nodes) < 2 {
+		return nil
+	}
+	switch topology {
+	case perfv1.TopologyPairwise:
+		for i := 0; i+1 < len(nodes); i += 2 {
+			pairs = append(pairs, iperf3Pair{Source: nodes[i], Destination: nodes[i+1]})
+		}
+	case perfv1.TopologyAllToAll:
+		for _, source := range nodes {
+			for _, destination := range nodes {
+				if source != destination {
+					pairs = append(pairs, iperf3Pair{Source: source, Destination: destination})
+				}
+			}
+		}
+	default:
+		for i, source := range nodes {
+			pairs = append(pairs, iperf3Pair{Source: source, Destination: nodes[(i+1)%len(nodes)]})
+		}
+	}
+	return pairs
+}
+
+// iperf3ClientArgs returns the arguments of the iperf3 clients, the server
+// being expanded from the IPERF3_SERVER environment variable
+func iperf3ClientArgs(b *perfv1.Baseline) []string {
+	iperf3 := iperf3Spec(b)
+	args := []string{"iperf3", "-c", "$(IPERF3_SERVER)", "-p", strconv.Itoa(iperf3Port),
+		"-t", strconv.Itoa(int(iperf3.Duration.Seconds())), "-P", strconv.Itoa(int(iperf3.Parallel)), "-J"}
+	if iperf3.Protocol == "UDP" {
+		args = append(args, "-u")
+	}
+	if iperf3.Bandwidth != "" {
+		args = append(args, "-b", iperf3.Bandwidth)
+	}
+	return args
+}
+
+// nodeMatches returns if the node labels match every one of the requirements
+func nodeMatches(node *corev1.Node, requirements []corev1.NodeSelectorRequirement) bool {
+	for _, r := range requirements {
+		value, exists := node.Labels[r.Key]
+		switch r.Operator {
+		case corev1.NodeSelectorOpExists:
+			if !exists {
+				return false
+			}
+		case corev1.NodeSelectorOpDoesNotExist:
+			if exists {
+				return false
+			}
+		case corev1.NodeSelectorOpIn:
+			if !exists || !contains(r.Values, value) {
+				return false
+			}
+		case corev1.NodeSelectorOpNotIn:
+			if exists && contains(r.Values, value) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// tolerates returns if the tolerations tolerate every scheduling taint
+func tolerates(tolerations []corev1.Toleration, taints []corev1.Taint) bool {
+	for i := range taints {
+		taint := &taints[i]
+		if taint.Effect == corev1.TaintEffectPreferNoSchedule {
+			continue
+		}
+		tolerated := false
+		for j := range tolerations {
+			if tolerations[j].ToleratesTaint(taint) {
+				tolerated = true
+				break
+			}
+		}
+		if !tolerated {
+			return false
+		}
+	}
+	return true
+}
+
+// iperf3Nodes returns the sorted names of the nodes the Baseline can run on
+func (r *BaselineReconciler) iperf3Nodes(ctx context.Context, b *perfv1.Baseline, requirements []corev1.NodeSelectorRequirement, excludedNodes []string) ([]string, error) {
+	nodes := &corev1.NodeList{}
+	err := r.List(ctx, nodes, client.MatchingLabelsSelector{Selector: labels.SelectorFromSet(b.Spec.NodeSelector)})
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for i := range nodes.Items {
+		node := &nodes.Items[i]
+		if contains(excludedNodes, node.Name) || !nodeMatches(node, requirements) || !tolerates(b.Spec.Tolerations, node.Spec.Taints) {
+			continue
+		}
+		names = append(names, node.Name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// labelsForIperf3 returns the labels of the iperf3 objects of a pair
+func labelsForIperf3(name string, p iperf3Pair, role string) map[string]string {
+	ls := labelsForBaseline(name)
+	ls["baseline_workload"] = "iperf3"
+	ls["baseline_pair"] = p.id()
+	ls["baseline_role"] = role
+	return ls
+}
+
+// iperf3ServerName returns the name of the server Deployment and Service of a pair
+func iperf3ServerName(b *perfv1.Baseline, p iperf3Pair) string {
+	return fmt.Sprintf("%s-iperf3-%s-server", b.Name, p.id())
+}
+
+// iperf3ClientName returns the name of the client Deployment of a pair
+func iperf3ClientName(b *perfv1.Baseline, p iperf3Pair) string {
+	return fmt.Sprintf("%s-iperf3-%s-client", b.Name, p.id())
+}
+
+// onNode returns the node affinity pinning a pod to the node
+func onNode(node string) *corev1.Affinity {
+	return &corev1.Affinity{
+		NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+					MatchFields: []corev1.NodeSelectorRequirement{{
+						Key:      "metadata.name",
+						Operator: corev1.NodeSelectorOpIn,
+						Values:   []string{node},
+					}},
+				}},
+			},
+		},
+	}
+}
+
+// iperf3Deployment returns the Deployment of one side of a pair
+func (r *BaselineReconciler) iperf3Deployment(b *perfv1.Baseline, p iperf3Pair, role string) *appsv1.Deployment {
+	ls := labelsForIperf3(b.Name, p, role)
+	replicas := int32(1)
+	container := corev1.Container{
+		Image:           b.Spec.Image,
+		ImagePullPolicy: b.Spec.ImagePullPolicy,
+		Resources:       b.Spec.Resources,
+	}
+	node := p.Destination
+	name := iperf3ServerName(b, p)
+	if role == "server" {
+		container.Name = "iperf3-server"
+		container.Command = []string{"iperf3", "-s", "-p", strconv.Itoa(iperf3Port)}
+		container.Ports = []corev1.ContainerPort{
+			{Name: "tcp", ContainerPort: iperf3Port, Protocol: corev1.ProtocolTCP},
+			{Name: "udp", ContainerPort: iperf3Port, Protocol: corev1.ProtocolUDP},
+		}
+	} else {
+		node = p.Source
+		name = iperf3ClientName(b, p)
+		container.Name = "iperf3-client"
+		container.Command = []string{"/bin/sh", "-c", iperf3Wrapper, "iperf3"}
+		container.Args = iperf3ClientArgs(b)
+		container.Env = []corev1.EnvVar{{Name: "IPERF3_SERVER", Value: iperf3ServerName(b, p)}}
+	}
+
+	dep := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: b.Namespace,
+			Labels:    ls,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: ls,
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: ls,
+				},
+				Spec: corev1.PodSpec{
+					Affinity:                      onNode(node),
+					Tolerations:                   b.Spec.Tolerations,
+					TerminationGracePeriodSeconds: terminationGracePeriod(b),
+					ImagePullSecrets:              b.Spec.ImagePullSecrets,
+					Containers:                    []corev1.Container{container},
+				},
+			},
+		},
+	}
+	// Set Baseline instance as the owner and controller
+	ctrl.SetControllerReference(b, dep, r.Scheme)
+	return dep
+}
+
+// iperf3Service returns the Service of the server of a pair
+func (r *BaselineReconciler) iperf3Service(b *perfv1.Baseline, p iperf3Pair) *corev1.Service {
+	ls := labelsForIperf3(b.Name, p, "server")
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      iperf3ServerName(b, p),
+			Namespace: b.Namespace,
+			Labels:    ls,
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: ls,
+			Ports: []corev1.ServicePort{
+				{Name: "tcp", Port: iperf3Port, TargetPort: intstr.FromInt(iperf3Port), Protocol: corev1.ProtocolTCP},
+				{Name: "udp", Port: iperf3Port, TargetPort: intstr.FromInt(iperf3Port), Protocol: corev1.ProtocolUDP},
+			},
+		},
+	}
+	// Set Baseline instance as the owner and controller
+	ctrl.SetControllerReference(b, svc, r.Scheme)
+	return svc
+}
+
+// reconcileIperf3 ensures a server Deployment and Service and a client
+// Deployment exist for every pair, and only for them
+func (r *BaselineReconciler) reconcileIperf3(ctx context.Context, b *perfv1.Baseline, pairs []iperf3Pair) error {
+	var deps []*appsv1.Deployment
+	var svcs []*corev1.Service
+	for _, p := range pairs {
+		deps = append(deps, r.iperf3Deployment(b, p, "server"), r.iperf3Deployment(b, p, "client"))
+		svcs = append(svcs, r.iperf3Service(b, p))
+	}
+	return r.reconcileDeployments(ctx, b, deps, svcs)
+}
+
+// runIperf3 runs the iperf3 tests between the nodes of the Baseline
+func (r *BaselineReconciler) runIperf3(ctx context.Context, b *perfv1.Baseline, requirements []corev1.NodeSelectorRequirement, excludedNodes []string) (ctrl.Result, error) {
+	log := ctrllog.FromContext(ctx)
+
+	err := r.deleteDaemonSets(ctx, b)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	nodes, err := r.iperf3Nodes(ctx, b, requirements, excludedNodes)
+	if err != nil {
+		log.Error(err, "Failed to list the nodes")
+		return ctrl.Result{}, err
+	}
+	pairs := iperf3Pairs(iperf3Spec(b).Topology, nodes)
+	if len(pairs) == 0 {
+		r.recorder.Event(b, "Warning", "NotEnoughNodes", fmt.Sprintf("The Iperf3 workload needs 2 nodes, %d available", len(nodes)))
+	}
+	err = r.reconcileIperf3(ctx, b, pairs)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	err = r.reconcileDisruptionBudget(ctx, b)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	err = r.updateDeploymentsCommand(ctx, b, iperf3ClientArgs(b))
+	if err != nil {
+		log.Error(err, "Failed to update Baseline status")
+		return ctrl.Result{}, err
+	}
+
+	err = r.collectIperf3Results(ctx, b, pairs)
+	if err != nil {
+		log.Error(err, "Failed to update Baseline status")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: iperf3Spec(b).Duration.Duration}, nil
+}
+
+// iperf3Output is the part of the iperf3 json output the results are taken from
+type iperf3Output struct {
+	Start struct {
+		Timestamp struct {
+			Timesecs int64 `json:"timesecs"`
+		} `json:"timestamp"`
+	} `json:"start"`
+	End struct {
+		SumSent struct {
+			Retransmits int64 `json:"retransmits"`
+		} `json:"sum_sent"`
+		SumReceived struct {
+			BitsPerSecond float64 `json:"bits_per_second"`
+		} `json:"sum_received"`
+		Sum struct {
+			BitsPerSecond float64 `json:"bits_per_second"`
+			JitterMs      float64 `json:"jitter_ms"`
+			LostPackets   int64   `json:"lost_packets"`
+		} `json:"sum"`
+	} `json:"end"`
+}
+
+// parseIperf3Result returns the results of the last test logged by an
+// iperf3 client
+func parseIperf3Result(logs []byte, p iperf3Pair) (*perfv1.Iperf3Result, error) {
+	var last string
+	scanner := bufio.NewScanner(bytes.NewReader(logs))
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		if line := scanner.Text(); strings.HasPrefix(line, iperf3ResultPrefix) {
+			last = strings.TrimPrefix(line, iperf3ResultPrefix)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if last == "" {
+		return nil, nil
+	}
+
+	output := iperf3Output{}
+	if err := json.Unmarshal([]byte(last), &output); err != nil {
+		return nil, err
+	}
+	result := &perfv1.Iperf3Result{
+		Source:      p.Source,
+		Destination: p.Destination,
+		Retransmits: output.End.SumSent.Retransmits,
+		Time:        metav1.NewTime(time.Unix(output.Start.Timestamp.Timesecs, 0)),
+	}
+	if output.End.SumReceived.BitsPerSecond > 0 {
+		result.BitsPerSecond = int64(output.End.SumReceived.BitsPerSecond)
+	} else {
+		// UDP tests only report a sum
+		result.BitsPerSecond = int64(output.End.Sum.BitsPerSecond)
+		result.LostPackets = output.End.Sum.LostPackets
+		result.Jitter.Duration = time.Duration(output.End.Sum.JitterMs * float64(time.Millisecond))
+	}
+	return result, nil
+}
+
+// collectIperf3Results records in the status and the metrics the results of
+// the last iperf3 test of every pair
+func (r *BaselineReconciler) collectIperf3Results(ctx context.Context, b *perfv1.Baseline, pairs []iperf3Pair) error {
+	log := ctrllog.FromContext(ctx)
+	if r.KubeClient == nil {
+		return nil
+	}
+
+	byID := map[string]iperf3Pair{}
+	for _, p := range pairs {
+		byID[p.id()] = p
+	}
+	pods := &corev1.PodList{}
+	err := r.List(ctx, pods, client.InNamespace(b.Namespace),
+		client.MatchingLabels{"baseline_cr": b.Name, "baseline_workload": "iperf3", "baseline_role": "client"})
+	if err != nil {
+		log.Error(err, "Failed to list the iperf3 clients")
+		return err
+	}
+
+	var results []perfv1.Iperf3Result
+	tailLines := int64(iperf3LogLines)
+	for _, pod := range pods.Items {
+		p, ok := byID[pod.Labels["baseline_pair"]]
+		if !ok || pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		logs, err := r.KubeClient.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{TailLines: &tailLines}).DoRaw(ctx)
+		if err != nil {
+			log.Error(err, "Failed to get the iperf3 logs", "Pod.Namespace", pod.Namespace, "Pod.Name", pod.Name)
+			continue
+		}
+		result, err := parseIperf3Result(logs, p)
+		if err != nil {
+			log.Error(err, "Failed to parse the iperf3 results", "Pod.Namespace", pod.Namespace, "Pod.Name", pod.Name)
+			continue
+		}
+		if result != nil {
+			results = append(results, *result)
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Source != results[j].Source {
+			return results[i].Source < results[j].Source
+		}
+		return results[i].Destination < results[j].Destination
+	})
+
+	setIperf3Metrics(b, results)
+	if equality.Semantic.DeepEqual(b.Status.Iperf3Results, results) {
+		return nil
+	}
+	b.Status.Iperf3Results = results
+	return r.updateStatus(ctx, b)
+}
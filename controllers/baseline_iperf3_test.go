@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/envtest/komega"
+
+	perfv1 "github.com/josecastillolema/baseline-operator/api/v1"
+)
+
+var _ = Describe("Iperf3 workload", func() {
+
+	const (
+		BaselineName      = "test-iperf3-baseline"
+		BaselineNamespace = "default"
+	)
+
+	Context("Pairing the nodes", func() {
+		It("Should follow the topology", func() {
+			nodes := []string{"a", "b", "c"}
+			Expect(iperf3Pairs(perfv1.TopologyRing, nodes)).Should(Equal([]iperf3Pair{
+				{Source: "a", Destination: "b"}, {Source: "b", Destination: "c"}, {Source: "c", Destination: "a"},
+			}))
+			Expect(iperf3Pairs(perfv1.TopologyPairwise, nodes)).Should(Equal([]iperf3Pair{
+				{Source: "a", Destination: "b"},
+			}))
+			Expect(iperf3Pairs(perfv1.TopologyAllToAll, nodes)).Should(HaveLen(6))
+			Expect(iperf3Pairs(perfv1.TopologyRing, []string{"a"})).Should(BeEmpty())
+		})
+	})
+
+	Context("Selecting the nodes", func() {
+		It("Should skip the nodes with untolerated taints", func() {
+			taints := []corev1.Taint{{Key: "dedicated", Value: "db", Effect: corev1.TaintEffectNoSchedule}}
+			Expect(tolerates(nil, taints)).Should(BeFalse())
+			Expect(tolerates([]corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpExists}}, taints)).Should(BeTrue())
+			Expect(tolerates(nil, []corev1.Taint{{Key: "soft", Effect: corev1.TaintEffectPreferNoSchedule}})).Should(BeTrue())
+		})
+	})
+
+	Context("Parsing the iperf3 logs", func() {
+		It("Should take the results of the last test", func() {
+			p := iperf3Pair{Source: "node-1", Destination: "node-2"}
+			logs := []byte(`IPERF3_RESULT {"start": {"timestamp": {"timesecs": 1000}}, "end": {"sum_sent": {"retransmits": 3}, "sum_received": {"bits_per_second": 9.4e9}}}
+IPERF3_RESULT {"start": {"timestamp": {"timesecs": 1000}}, "end": {"sum": {"bits_per_second": 1e9, "jitter_ms": 0.5, "lost_packets": 7}}}
+`)
+			result, err := parseIperf3Result(logs, p)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(result.Source).Should(Equal("node-1"))
+			Expect(result.Destination).Should(Equal("node-2"))
+			Expect(result.BitsPerSecond).Should(Equal(int64(1e9)))
+			Expect(result.LostPackets).Should(Equal(int64(7)))
+			Expect(result.Jitter.Duration).Should(Equal(500 * time.Microsecond))
+
+			result, err = parseIperf3Result(logs[:bytes.IndexByte(logs, '\n')], p)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(result.BitsPerSecond).Should(Equal(int64(9.4e9)))
+			Expect(result.Retransmits).Should(Equal(int64(3)))
+
+			result, err = parseIperf3Result([]byte("IPERF3_RESULT {\"start\"\n"), p)
+			Expect(err).Should(HaveOccurred())
+			Expect(result).Should(BeNil())
+		})
+	})
+
+	Context("Running the Iperf3 workload", func() {
+		It("Should deploy a client and a server per pair", func() {
+			ctx := context.Background()
+			for _, name := range []string{"iperf3-node-1", "iperf3-node-2"} {
+				node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"iperf3": "true"}}}
+				Expect(k8sClient.Create(ctx, node)).Should(Succeed())
+			}
+			baseline := &perfv1.Baseline{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      BaselineName,
+					Namespace: BaselineNamespace,
+				},
+				Spec: perfv1.BaselineSpec{
+					Workload:     perfv1.WorkloadIperf3,
+					Iperf3:       &perfv1.Iperf3Workload{Topology: perfv1.TopologyPairwise, Parallel: 4},
+					NodeSelector: map[string]string{"iperf3": "true"},
+				},
+			}
+			Expect(k8sClient.Create(ctx, baseline)).Should(Succeed())
+
+			p := iperf3Pair{Source: "iperf3-node-1", Destination: "iperf3-node-2"}
+			client := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: iperf3ClientName(baseline, p), Namespace: BaselineNamespace}}
+			Eventually(komega.Object(client)).Should(HaveField("Spec.Template.Spec.Containers", HaveLen(1)))
+			Expect(client.Spec.Template.Spec.Containers[0].Args).Should(ContainElements("-P", "4"))
+			Expect(client.Spec.Template.Spec.Containers[0].Image).Should(Equal(perfv1.DefaultIperf3Image))
+
+			svc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: iperf3ServerName(baseline, p), Namespace: BaselineNamespace}}
+			Eventually(komega.Object(svc)).Should(HaveField("Spec.Ports", HaveLen(2)))
+			Eventually(komega.Object(baseline)).Should(HaveField("Status.Command", ContainSubstring("-P 4")))
+		})
+	})
+})
@@ -0,0 +1,149 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	perfv1 "github.com/josecastillolema/baseline-operator/api/v1"
+)
+
+const (
+	// hugepagesVolumeName is the name of the hugetlbfs volume
+	hugepagesVolumeName = "hugepages"
+	// hugepagesPath is where the hugetlbfs volume is mounted
+	hugepagesPath = "/hugepages"
+)
+
+// hugepageSize returns the size of the hugepages of the Baseline
+func hugepageSize(h *perfv1.Hugepages) perfv1.HugepageSize {
+	if h.Size == "" {
+		return perfv1.Hugepages2Mi
+	}
+	return h.Size
+}
+
+// hugepageArgs returns the stress-ng arguments mapping the hugepages. Only
+// the mmaphuge workers map the hugepages of the pool requested by the pod,
+// with MAP_HUGETLB. The vm workers are only advised to use transparent
+// hugepages, which the kernel backs outside of that pool
+func hugepageArgs(b *perfv1.Baseline) ([]string, error) {
+	h := b.Spec.Hugepages
+	if h == nil {
+		return nil, nil
+	}
+	size := resource.MustParse(string(hugepageSize(h)))
+	if h.Amount.Sign() <= 0 || h.Amount.Value()%size.Value() != 0 {
+		return nil, fmt.Errorf("hugepages amount %s is not a multiple of the hugepage size %s", h.Amount.String(), size.String())
+	}
+	workers := int32(1)
+	if h.Workers != nil {
+		workers = *h.Workers
+	}
+	var args []string
+	if workers > 0 {
+		args = append(args, "--mmaphuge", strconv.Itoa(int(workers)))
+	}
+	if b.Spec.Memory != "" {
+		args = append(args, "--vm-madvise", "hugepage")
+	}
+	return args, nil
+}
+
+// hugepageResources requests the hugepages of the Baseline, if any
+func hugepageResources(resources *corev1.ResourceRequirements, b *perfv1.Baseline) {
+	h := b.Spec.Hugepages
+	if h == nil {
+		return
+	}
+	// Hugepages can not be overcommitted, their requests equal their limits
+	name := corev1.ResourceName(corev1.ResourceHugePagesPrefix + string(hugepageSize(h)))
+	if resources.Limits == nil {
+		resources.Limits = corev1.ResourceList{}
+	}
+	if resources.Requests == nil {
+		resources.Requests = corev1.ResourceList{}
+	}
+	resources.Limits[name] = h.Amount
+	resources.Requests[name] = h.Amount
+}
+
+// requestsCPUOrMemory returns if the resources request or limit cpu or
+// memory, which Kubernetes requires from a container requesting hugepages
+func requestsCPUOrMemory(resources *corev1.ResourceRequirements) bool {
+	for _, list := range []corev1.ResourceList{resources.Requests, resources.Limits} {
+		if _, ok := list[corev1.ResourceCPU]; ok {
+			return true
+		}
+		if _, ok := list[corev1.ResourceMemory]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// hugepagesVolume returns the hugetlbfs volume of the Baseline and its mount.
+// The mmaphuge workers do not use it, it is there for the custom stressors
+// mapping hugetlbfs files
+func hugepagesVolume(b *perfv1.Baseline) (corev1.Volume, corev1.VolumeMount) {
+	medium := corev1.StorageMedium(string(corev1.StorageMediumHugePagesPrefix) + string(hugepageSize(b.Spec.Hugepages)))
+	return corev1.Volume{
+		Name:         hugepagesVolumeName,
+		VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{Medium: medium}},
+	}, corev1.VolumeMount{
+		Name:      hugepagesVolumeName,
+		MountPath: hugepagesPath,
+	}
+}
+
+// numaArgs returns the stress-ng arguments of the numa workers
+func numaArgs(b *perfv1.Baseline) []string {
+	if b.Spec.NUMA == nil || b.Spec.NUMA.Workers == 0 {
+		return nil
+	}
+	return []string{"--numa", strconv.Itoa(int(b.Spec.NUMA.Workers))}
+}
+
+// numactlArgs returns the numactl command stress-ng is run under to apply
+// the memory policy of the Baseline, or nil if it has none
+func numactlArgs(b *perfv1.Baseline) ([]string, error) {
+	n := b.Spec.NUMA
+	if n == nil {
+		return nil, nil
+	}
+	if n.Nodes == "" {
+		return nil, fmt.Errorf("numa requires nodes")
+	}
+	switch n.Policy {
+	case perfv1.NUMABind, "":
+		return []string{"numactl", "--membind=" + n.Nodes, "--cpunodebind=" + n.Nodes, "--"}, nil
+	case perfv1.NUMAPreferred:
+		if strings.ContainsAny(n.Nodes, ",-") {
+			return nil, fmt.Errorf("the Preferred numa policy takes a single node, not %s", n.Nodes)
+		}
+		return []string{"numactl", "--preferred=" + n.Nodes, "--"}, nil
+	case perfv1.NUMAInterleave:
+		return []string{"numactl", "--interleave=" + n.Nodes, "--"}, nil
+	default:
+		return nil, fmt.Errorf("unknown numa policy %s", n.Policy)
+	}
+}
@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	perfv1 "github.com/josecastillolema/baseline-operator/api/v1"
+)
+
+var _ = Describe("Hugepages and NUMA", func() {
+
+	Context("Backing the memory stressors with hugepages", func() {
+		It("Should request the hugepages and mount them", func() {
+			b := &perfv1.Baseline{Spec: perfv1.BaselineSpec{
+				Memory:    "1G",
+				Hugepages: &perfv1.Hugepages{Amount: resource.MustParse("1Gi")},
+				Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1G")}},
+			}}
+			command, err := commandForBaseline(b)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(command).Should(Equal([]string{"stress-ng", "-t", "0",
+				"--vm", "1", "--vm-bytes", "1G", "--mmaphuge", "1", "--vm-madvise", "hugepage"}))
+
+			r := &BaselineReconciler{Scheme: scheme.Scheme}
+			ds, err := r.daemonsetForBaseline(b, nil)
+			Expect(err).ShouldNot(HaveOccurred())
+			container := ds.Spec.Template.Spec.Containers[0]
+			Expect(container.Resources.Limits).Should(HaveKeyWithValue(corev1.ResourceName("hugepages-2Mi"), resource.MustParse("1Gi")))
+			Expect(container.Resources.Requests).Should(HaveKeyWithValue(corev1.ResourceName("hugepages-2Mi"), resource.MustParse("1Gi")))
+			Expect(container.Resources.Requests).Should(HaveKey(corev1.ResourceMemory))
+			Expect(container.VolumeMounts).Should(ContainElement(HaveField("MountPath", "/hugepages")))
+			Expect(ds.Spec.Template.Spec.Volumes).Should(ContainElement(HaveField("EmptyDir.Medium", corev1.StorageMedium("HugePages-2Mi"))))
+			Expect(b.Spec.Resources.Limits).Should(BeNil())
+		})
+
+		It("Should require a multiple of the hugepage size", func() {
+			b := &perfv1.Baseline{Spec: perfv1.BaselineSpec{
+				Hugepages: &perfv1.Hugepages{Size: perfv1.Hugepages1Gi, Amount: resource.MustParse("512Mi")},
+			}}
+			_, err := commandForBaseline(b)
+			Expect(err).Should(HaveOccurred())
+		})
+
+		It("Should require a cpu or memory request", func() {
+			b := &perfv1.Baseline{Spec: perfv1.BaselineSpec{
+				Hugepages: &perfv1.Hugepages{Amount: resource.MustParse("1Gi")},
+			}}
+			r := &BaselineReconciler{Scheme: scheme.Scheme}
+			_, err := r.daemonsetForBaseline(b, nil)
+			Expect(err).Should(MatchError(ContainSubstring("cpu or memory")))
+
+			b.Spec.Resources.Limits = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}
+			_, err = r.daemonsetForBaseline(b, nil)
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+	})
+
+	Context("Binding the memory to NUMA nodes", func() {
+		It("Should run stress-ng under numactl", func() {
+			cpu := int32(1)
+			b := &perfv1.Baseline{Spec: perfv1.BaselineSpec{Cpu: &cpu, NUMA: &perfv1.NUMA{Nodes: "0-1", Workers: 2}}}
+			command, err := commandForBaseline(b)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(command).Should(Equal([]string{"numactl", "--membind=0-1", "--cpunodebind=0-1", "--",
+				"stress-ng", "-t", "0", "--cpu", "1", "--numa", "2"}))
+
+			b.Spec.NUMA.Policy = perfv1.NUMAInterleave
+			command, err = commandForBaseline(b)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(command[:2]).Should(Equal([]string{"numactl", "--interleave=0-1"}))
+		})
+
+		It("Should take a single node for the Preferred policy", func() {
+			b := &perfv1.Baseline{Spec: perfv1.BaselineSpec{NUMA: &perfv1.NUMA{Policy: perfv1.NUMAPreferred, Nodes: "0,1"}}}
+			_, err := commandForBaseline(b)
+			Expect(err).Should(HaveOccurred())
+
+			b.Spec.NUMA.Nodes = "1"
+			command, err := commandForBaseline(b)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(command[:3]).Should(Equal([]string{"numactl", "--preferred=1", "--"}))
+		})
+	})
+})
@@ -0,0 +1,173 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	perfv1 "github.com/josecastillolema/baseline-operator/api/v1"
+)
+
+// defaultCoolDown is how long a node must stay healthy before the load
+// resumes when the Baseline does not set a cool-down
+const defaultCoolDown = 5 * time.Minute
+
+// pressureConditions are the node conditions meaning the node is unhealthy
+// when true
+var pressureConditions = []corev1.NodeConditionType{
+	corev1.NodeMemoryPressure,
+	corev1.NodeDiskPressure,
+	corev1.NodePIDPressure,
+}
+
+// nodeUnhealthyReason returns why the node is unhealthy, or an empty string
+// if it is healthy
+func nodeUnhealthyReason(node *corev1.Node) string {
+	var reasons []string
+	ready := false
+	for _, c := range node.Status.Conditions {
+		if c.Type == corev1.NodeReady {
+			ready = c.Status == corev1.ConditionTrue
+		}
+	}
+	if !ready {
+		reasons = append(reasons, "NotReady")
+	}
+	for _, t := range pressureConditions {
+		for _, c := range node.Status.Conditions {
+			if c.Type == t && c.Status == corev1.ConditionTrue {
+				reasons = append(reasons, string(t))
+			}
+		}
+	}
+	return strings.Join(reasons, ",")
+}
+
+// updateNodeHealth records in the status the unhealthy nodes matching the
+// node selector of the Baseline, whether its pods run on them or not, and
+// forgets the ones healthy for longer than the cool-down.
+// It returns if the status changed and when the next cool-down ends
+func (r *BaselineReconciler) updateNodeHealth(ctx context.Context, b *perfv1.Baseline) (bool, time.Duration, error) {
+	nodes := &corev1.NodeList{}
+	err := r.List(ctx, nodes, client.MatchingLabelsSelector{Selector: labels.SelectorFromSet(b.Spec.NodeSelector)})
+	if err != nil {
+		return false, 0, err
+	}
+
+	now := metav1.Now()
+	coolDown := coolDownForBaseline(b)
+	aborted := map[string]*perfv1.NodeAbort{}
+	for i := range b.Status.AbortedNodes {
+		aborted[b.Status.AbortedNodes[i].Node] = &b.Status.AbortedNodes[i]
+	}
+
+	var result []perfv1.NodeAbort
+	var requeueAfter time.Duration
+	changed := false
+	for i := range nodes.Items {
+		node := &nodes.Items[i]
+		reason := nodeUnhealthyReason(node)
+		abort, wasAborted := aborted[node.Name]
+		delete(aborted, node.Name)
+		switch {
+		case reason != "" && !wasAborted:
+			r.recorder.Event(b, "Warning", "NodeAborted", fmt.Sprintf("Removed the load from node %s: %s", node.Name, reason))
+			result = append(result, perfv1.NodeAbort{Node: node.Name, Reason: reason, AbortTime: now})
+			changed = true
+		case reason != "":
+			if abort.Reason != reason || abort.HealthyTime != nil {
+				abort.Reason = reason
+				abort.HealthyTime = nil
+				changed = true
+			}
+			result = append(result, *abort)
+		case wasAborted:
+			if abort.HealthyTime == nil {
+				abort.HealthyTime = &now
+				changed = true
+			}
+			remaining := coolDown - now.Sub(abort.HealthyTime.Time)
+			if remaining <= 0 {
+				r.recorder.Event(b, "Normal", "NodeResumed", fmt.Sprintf("Resumed the load on node %s", node.Name))
+				changed = true
+				continue
+			}
+			if requeueAfter == 0 || remaining < requeueAfter {
+				requeueAfter = remaining
+			}
+			result = append(result, *abort)
+		}
+	}
+	// The nodes no longer matching the Baseline, or deleted, are forgotten
+	if len(aborted) > 0 {
+		changed = true
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Node < result[j].Node })
+	b.Status.AbortedNodes = result
+	return changed, requeueAfter, nil
+}
+
+// abortedNodeNames returns the names of the nodes the load was removed from
+func abortedNodeNames(b *perfv1.Baseline) []string {
+	var names []string
+	for _, a := range b.Status.AbortedNodes {
+		names = append(names, a.Node)
+	}
+	return names
+}
+
+// coolDownForBaseline returns how long a node must stay healthy before the
+// load resumes on it
+func coolDownForBaseline(b *perfv1.Baseline) time.Duration {
+	if b.Spec.CoolDown == nil {
+		return defaultCoolDown
+	}
+	return b.Spec.CoolDown.Duration
+}
+
+// baselinesForNode returns a request for every Baseline when the health of
+// a node changes
+func (r *BaselineReconciler) baselinesForNode(o client.Object) []reconcile.Request {
+	return r.allBaselines()
+}
+
+// nodeHealthChanged filters the node events to the ones changing its health
+var nodeHealthChanged = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldNode, ok := e.ObjectOld.(*corev1.Node)
+		if !ok {
+			return false
+		}
+		newNode, ok := e.ObjectNew.(*corev1.Node)
+		if !ok {
+			return false
+		}
+		return nodeUnhealthyReason(oldNode) != nodeUnhealthyReason(newNode)
+	},
+}
@@ -0,0 +1,98 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/envtest/komega"
+
+	perfv1 "github.com/josecastillolema/baseline-operator/api/v1"
+)
+
+var _ = Describe("Node health", func() {
+
+	const (
+		NodeName          = "test-unhealthy-node"
+		BaselineName      = "test-nodehealth-baseline"
+		BaselineNamespace = "default"
+	)
+
+	Context("Checking the health of a node", func() {
+		It("Should report the unhealthy conditions", func() {
+			node := &corev1.Node{Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{
+				{Type: corev1.NodeReady, Status: corev1.ConditionTrue},
+				{Type: corev1.NodeMemoryPressure, Status: corev1.ConditionFalse},
+			}}}
+			Expect(nodeUnhealthyReason(node)).Should(BeEmpty())
+
+			node.Status.Conditions[1].Status = corev1.ConditionTrue
+			Expect(nodeUnhealthyReason(node)).Should(Equal("MemoryPressure"))
+
+			node.Status.Conditions[0].Status = corev1.ConditionUnknown
+			Expect(nodeUnhealthyReason(node)).Should(Equal("NotReady,MemoryPressure"))
+		})
+
+		It("Should exclude the aborted nodes from the affinity", func() {
+			Expect(nodeAffinity(nil, nil)).Should(BeNil())
+			affinity := nodeAffinity(nil, []string{"node-1"})
+			terms := affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+			Expect(terms).Should(HaveLen(1))
+			Expect(terms[0].MatchFields).Should(Equal([]corev1.NodeSelectorRequirement{{
+				Key:      "metadata.name",
+				Operator: corev1.NodeSelectorOpNotIn,
+				Values:   []string{"node-1"},
+			}}))
+		})
+	})
+
+	Context("Stressing an unhealthy node", func() {
+		It("Should remove the load from it until it cools down", func() {
+			By("By creating a node under memory pressure")
+			ctx := context.Background()
+			node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{
+				Name:   NodeName,
+				Labels: map[string]string{"nodehealth": "true"},
+			}}
+			Expect(k8sClient.Create(ctx, node)).Should(Succeed())
+			node.Status.Conditions = []corev1.NodeCondition{
+				{Type: corev1.NodeReady, Status: corev1.ConditionTrue},
+				{Type: corev1.NodeMemoryPressure, Status: corev1.ConditionTrue},
+			}
+			Expect(k8sClient.Status().Update(ctx, node)).Should(Succeed())
+
+			By("By creating a Baseline selecting it")
+			cpu := int32(1)
+			baseline := &perfv1.Baseline{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      BaselineName,
+					Namespace: BaselineNamespace,
+				},
+				Spec: perfv1.BaselineSpec{
+					Cpu:          &cpu,
+					NodeSelector: map[string]string{"nodehealth": "true"},
+					CoolDown:     &metav1.Duration{Duration: time.Second},
+					// Keep the affinity only excluding the aborted nodes
+					AllowControlPlane: true,
+				},
+			}
+			Expect(k8sClient.Create(ctx, baseline)).Should(Succeed())
+			Eventually(komega.Object(baseline)).Should(HaveField("Status.AbortedNodes", ContainElement(
+				HaveField("Reason", Equal("MemoryPressure")))))
+			ds := &appsv1.DaemonSet{ObjectMeta: metav1.ObjectMeta{Name: BaselineName, Namespace: BaselineNamespace}}
+			Eventually(komega.Object(ds)).Should(HaveField("Spec.Template.Spec.Affinity", Not(BeNil())))
+
+			By("By relieving the memory pressure")
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: NodeName}, node)).Should(Succeed())
+			node.Status.Conditions[1].Status = corev1.ConditionFalse
+			Expect(k8sClient.Status().Update(ctx, node)).Should(Succeed())
+			Eventually(komega.Object(baseline)).Should(HaveField("Status.AbortedNodes", BeEmpty()))
+			Eventually(komega.Object(ds)).Should(HaveField("Spec.Template.Spec.Affinity", BeNil()))
+		})
+	})
+})
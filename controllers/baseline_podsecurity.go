@@ -0,0 +1,310 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	perfv1 "github.com/josecastillolema/baseline-operator/api/v1"
+)
+
+// podSecurityEnforceLabel is the namespace label setting the Pod Security
+// level the pods are rejected under
+const podSecurityEnforceLabel = "pod-security.kubernetes.io/enforce"
+
+// Pod Security levels, from the least to the most restrictive
+const (
+	podSecurityPrivileged = "privileged"
+	podSecurityBaseline   = "baseline"
+	podSecurityRestricted = "restricted"
+)
+
+// baselineCapabilities are the capabilities the baseline level allows to add
+var baselineCapabilities = []string{
+	"AUDIT_WRITE", "CHOWN", "DAC_OVERRIDE", "FOWNER", "FSETID", "KILL", "MKNOD",
+	"NET_BIND_SERVICE", "SETFCAP", "SETGID", "SETPCAP", "SETUID", "SYS_CHROOT",
+}
+
+// baselineSysctls are the safe sysctls the baseline level allows
+var baselineSysctls = []string{
+	"kernel.shm_rmid_forced", "net.ipv4.ip_local_port_range", "net.ipv4.ip_unprivileged_port_start",
+	"net.ipv4.tcp_syncookies", "net.ipv4.ping_group_range",
+}
+
+// baselineSELinuxTypes are the SELinux types the baseline level allows
+var baselineSELinuxTypes = []string{"", "container_t", "container_init_t", "container_kvm_t"}
+
+// appArmorAnnotationPrefix prefixes the annotations setting the AppArmor
+// profile of the containers
+const appArmorAnnotationPrefix = "container.apparmor.security.beta.kubernetes.io/"
+
+// podSecurityViolations returns the fields of the pod template violating the
+// Pod Security level, following the checks of the Pod Security admission.
+// An unknown level is evaluated as restricted, like the admission does
+func podSecurityViolations(level string, template *corev1.PodTemplateSpec) []string {
+	if level == podSecurityPrivileged {
+		return nil
+	}
+	spec := &template.Spec
+	// The init containers added by the pod template overlay are checked too
+	containers := append(append([]corev1.Container(nil), spec.InitContainers...), spec.Containers...)
+	var violations []string
+	if spec.HostNetwork || spec.HostPID || spec.HostIPC {
+		violations = append(violations, fmt.Sprintf("host namespaces (hostNetwork=%t, hostPID=%t, hostIPC=%t)", spec.HostNetwork, spec.HostPID, spec.HostIPC))
+	}
+	for _, v := range spec.Volumes {
+		if v.HostPath != nil {
+			violations = append(violations, fmt.Sprintf("hostPath volumes (volume %q)", v.Name))
+		}
+	}
+	pod := spec.SecurityContext
+	if pod == nil {
+		pod = &corev1.PodSecurityContext{}
+	}
+	for _, sysctl := range pod.Sysctls {
+		if !contains(baselineSysctls, sysctl.Name) {
+			violations = append(violations, fmt.Sprintf("forbidden sysctls (%s)", sysctl.Name))
+		}
+	}
+	if reason := seLinuxViolation(pod.SELinuxOptions); reason != "" {
+		violations = append(violations, fmt.Sprintf("seLinuxOptions (pod %s)", reason))
+	}
+	for key, profile := range template.Annotations {
+		if !strings.HasPrefix(key, appArmorAnnotationPrefix) {
+			continue
+		}
+		if profile != "runtime/default" && !strings.HasPrefix(profile, "localhost/") {
+			violations = append(violations, fmt.Sprintf("forbidden AppArmor profile (annotation %s=%q)", key, profile))
+		}
+	}
+	for _, c := range containers {
+		sc := c.SecurityContext
+		if sc == nil {
+			sc = &corev1.SecurityContext{}
+		}
+		if reason := seLinuxViolation(sc.SELinuxOptions); reason != "" {
+			violations = append(violations, fmt.Sprintf("seLinuxOptions (container %q %s)", c.Name, reason))
+		}
+		if sc.Privileged != nil && *sc.Privileged {
+			violations = append(violations, fmt.Sprintf("privileged (container %q must not set securityContext.privileged=true)", c.Name))
+		}
+		if sc.Capabilities != nil {
+			for _, capability := range sc.Capabilities.Add {
+				if !contains(baselineCapabilities, string(capability)) {
+					violations = append(violations, fmt.Sprintf("non-default capabilities (container %q must not include %s in securityContext.capabilities.add)", c.Name, capability))
+				}
+			}
+		}
+		for _, port := range c.Ports {
+			if port.HostPort != 0 {
+				violations = append(violations, fmt.Sprintf("hostPort (container %q uses hostPort %d)", c.Name, port.HostPort))
+			}
+		}
+		if sc.ProcMount != nil && *sc.ProcMount != corev1.DefaultProcMount {
+			violations = append(violations, fmt.Sprintf("procMount (container %q must not set securityContext.procMount=%s)", c.Name, *sc.ProcMount))
+		}
+		if seccompType(sc.SeccompProfile, pod.SeccompProfile) == corev1.SeccompProfileTypeUnconfined {
+			violations = append(violations, fmt.Sprintf("seccompProfile (container %q must not set securityContext.seccompProfile.type to Unconfined)", c.Name))
+		}
+	}
+	if level == podSecurityBaseline {
+		return violations
+	}
+
+	for _, v := range spec.Volumes {
+		if v.ConfigMap == nil && v.CSI == nil && v.DownwardAPI == nil && v.EmptyDir == nil && v.Ephemeral == nil &&
+			v.PersistentVolumeClaim == nil && v.Projected == nil && v.Secret == nil && v.HostPath == nil {
+			violations = append(violations, fmt.Sprintf("restricted volume types (volume %q)", v.Name))
+		}
+	}
+	for _, c := range containers {
+		sc := c.SecurityContext
+		if sc == nil {
+			sc = &corev1.SecurityContext{}
+		}
+		if sc.AllowPrivilegeEscalation == nil || *sc.AllowPrivilegeEscalation {
+			violations = append(violations, fmt.Sprintf("allowPrivilegeEscalation != false (container %q must set securityContext.allowPrivilegeEscalation=false)", c.Name))
+		}
+		if sc.Capabilities == nil || !containsCapability(sc.Capabilities.Drop, "ALL") {
+			violations = append(violations, fmt.Sprintf("unrestricted capabilities (container %q must set securityContext.capabilities.drop=[\"ALL\"])", c.Name))
+		}
+		if sc.Capabilities != nil {
+			for _, capability := range sc.Capabilities.Add {
+				if capability != "NET_BIND_SERVICE" && contains(baselineCapabilities, string(capability)) {
+					violations = append(violations, fmt.Sprintf("unrestricted capabilities (container %q must not include %s in securityContext.capabilities.add)", c.Name, capability))
+				}
+			}
+		}
+		nonRoot := sc.RunAsNonRoot
+		if nonRoot == nil {
+			nonRoot = pod.RunAsNonRoot
+		}
+		if nonRoot == nil || !*nonRoot {
+			violations = append(violations, fmt.Sprintf("runAsNonRoot != true (container %q must set securityContext.runAsNonRoot=true)", c.Name))
+		}
+		user := sc.RunAsUser
+		if user == nil {
+			user = pod.RunAsUser
+		}
+		if user != nil && *user == 0 {
+			violations = append(violations, fmt.Sprintf("runAsUser=0 (container %q must not set runAsUser=0)", c.Name))
+		}
+		if t := seccompType(sc.SeccompProfile, pod.SeccompProfile); t != corev1.SeccompProfileTypeRuntimeDefault && t != corev1.SeccompProfileTypeLocalhost {
+			violations = append(violations, fmt.Sprintf("seccompProfile (container %q must set securityContext.seccompProfile.type to RuntimeDefault or Localhost)", c.Name))
+		}
+	}
+	return violations
+}
+
+// seLinuxViolation returns why the SELinux options are forbidden by the
+// baseline level, or an empty string if they are allowed
+func seLinuxViolation(options *corev1.SELinuxOptions) string {
+	if options == nil {
+		return ""
+	}
+	if !contains(baselineSELinuxTypes, options.Type) {
+		return fmt.Sprintf("must not set seLinuxOptions.type=%s", options.Type)
+	}
+	if options.User != "" || options.Role != "" {
+		return "must not set seLinuxOptions.user or seLinuxOptions.role"
+	}
+	return ""
+}
+
+// seccompType returns the seccomp profile type of a container, inherited
+// from the pod if the container does not set it
+func seccompType(container, pod *corev1.SeccompProfile) corev1.SeccompProfileType {
+	if container != nil {
+		return container.Type
+	}
+	if pod != nil {
+		return pod.Type
+	}
+	return ""
+}
+
+// containsCapability returns if the list contains the capability
+func containsCapability(list []corev1.Capability, capability corev1.Capability) bool {
+	for _, c := range list {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// podTemplatesForBaseline returns the namespace the pods of the Baseline run
+// in and their templates, the image probe Job included
+func (r *BaselineReconciler) podTemplatesForBaseline(b *perfv1.Baseline) (string, []*corev1.PodTemplateSpec) {
+	var templates []*corev1.PodTemplateSpec
+	switch b.Spec.Workload {
+	case perfv1.WorkloadStressNG, perfv1.WorkloadFio:
+		// The invalid spec is reported when creating the daemonset
+		if ds, err := r.daemonsetForBaseline(b, nil); err == nil {
+			templates = append(templates, &ds.Spec.Template)
+		}
+	case perfv1.WorkloadChurn:
+		pod := r.churnPod(b, nil)
+		templates = append(templates, &corev1.PodTemplateSpec{ObjectMeta: pod.ObjectMeta, Spec: pod.Spec})
+	case perfv1.WorkloadHTTP:
+		for _, role := range []string{"server", "client"} {
+			templates = append(templates, &r.httpDeployment(b, role, nil).Spec.Template)
+		}
+	case perfv1.WorkloadIperf3:
+		for _, role := range []string{"server", "client"} {
+			templates = append(templates, &r.iperf3Deployment(b, iperf3Pair{}, role).Spec.Template)
+		}
+	case perfv1.WorkloadAPIServer:
+		// The load generators run in the sandbox namespace
+		return apiServerSpec(b).Namespace, []*corev1.PodTemplateSpec{&apiLoadDeployment(b, nil).Spec.Template}
+	}
+	if r.ProbeImages && r.KubeClient != nil && b.Spec.Workload == perfv1.WorkloadStressNG {
+		templates = append(templates, &r.imageProbeJob(b).Spec.Template)
+	}
+	return b.Namespace, templates
+}
+
+// podSecurityRefusal returns why the namespace the pods of the Baseline run
+// in would reject them, or an empty string if it admits them
+func (r *BaselineReconciler) podSecurityRefusal(ctx context.Context, b *perfv1.Baseline) (string, error) {
+	namespace, templates := r.podTemplatesForBaseline(b)
+	ns := &corev1.Namespace{}
+	err := r.Get(ctx, types.NamespacedName{Name: namespace}, ns)
+	if err != nil {
+		if errors.IsNotFound(err) && namespace != b.Namespace {
+			// The sandbox namespace is evaluated once created
+			return "", nil
+		}
+		return "", err
+	}
+	level, enforced := ns.Labels[podSecurityEnforceLabel]
+	if !enforced {
+		return "", nil
+	}
+	var violations []string
+	for _, template := range templates {
+		for _, violation := range podSecurityViolations(level, template) {
+			if !contains(violations, violation) {
+				violations = append(violations, violation)
+			}
+		}
+	}
+	if len(violations) == 0 {
+		return "", nil
+	}
+	return fmt.Sprintf("namespace %s enforces the %s Pod Security level, violated by: %s", namespace, level, strings.Join(violations, ", ")), nil
+}
+
+// baselinesForNamespace returns a request for every Baseline of the namespace
+func (r *BaselineReconciler) baselinesForNamespace(o client.Object) []reconcile.Request {
+	baselines := &perfv1.BaselineList{}
+	err := r.List(context.Background(), baselines, client.InNamespace(o.GetName()))
+	if err != nil {
+		ctrllog.Log.Error(err, "Failed to list the Baselines of the namespace", "Namespace.Name", o.GetName())
+		return nil
+	}
+	requests := make([]reconcile.Request, len(baselines.Items))
+	for i, b := range baselines.Items {
+		requests[i] = reconcile.Request{NamespacedName: types.NamespacedName{Name: b.Name, Namespace: b.Namespace}}
+	}
+	return requests
+}
+
+// podSecurityChanged filters the namespace events to the ones changing its
+// enforced Pod Security level
+var podSecurityChanged = predicate.Funcs{
+	CreateFunc: func(e event.CreateEvent) bool {
+		return false
+	},
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		return e.ObjectOld.GetLabels()[podSecurityEnforceLabel] != e.ObjectNew.GetLabels()[podSecurityEnforceLabel]
+	},
+	DeleteFunc: func(e event.DeleteEvent) bool {
+		return false
+	},
+}
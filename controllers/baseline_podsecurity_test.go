@@ -0,0 +1,145 @@
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	perfv1 "github.com/josecastillolema/baseline-operator/api/v1"
+)
+
+var _ = Describe("Pod Security levels", func() {
+
+	var r *BaselineReconciler
+	hostNetwork := true
+
+	BeforeEach(func() {
+		r = &BaselineReconciler{Scheme: scheme.Scheme}
+	})
+
+	podSpec := func(b *perfv1.Baseline) *corev1.PodTemplateSpec {
+		ds, err := r.daemonsetForBaseline(b, nil)
+		Expect(err).ShouldNot(HaveOccurred())
+		return &ds.Spec.Template
+	}
+
+	Context("Evaluating the stress pods", func() {
+		It("Should admit any pod in the privileged level", func() {
+			b := &perfv1.Baseline{Spec: perfv1.BaselineSpec{Workload: perfv1.WorkloadStressNG, HostNetwork: &hostNetwork}}
+			Expect(podSecurityViolations("privileged", podSpec(b))).Should(BeEmpty())
+		})
+
+		It("Should reject the host network and the privileged capabilities in the baseline level", func() {
+			b := &perfv1.Baseline{Spec: perfv1.BaselineSpec{Workload: perfv1.WorkloadStressNG, HostNetwork: &hostNetwork}}
+			Expect(podSecurityViolations("baseline", podSpec(b))).Should(ConsistOf(ContainSubstring("hostNetwork=true")))
+
+			b = &perfv1.Baseline{Spec: perfv1.BaselineSpec{
+				Workload:     perfv1.WorkloadStressNG,
+				SecurityMode: perfv1.SecurityAuto,
+				CustomArgs:   []string{"--rawsock", "1"},
+			}}
+			Expect(podSecurityViolations("baseline", podSpec(b))).Should(ConsistOf(ContainSubstring("NET_RAW")))
+		})
+
+		It("Should only admit the Auto security mode without capabilities in the restricted level", func() {
+			cpu := int32(1)
+			b := &perfv1.Baseline{Spec: perfv1.BaselineSpec{Workload: perfv1.WorkloadStressNG, Cpu: &cpu}}
+			Expect(podSecurityViolations("restricted", podSpec(b))).Should(ConsistOf(
+				ContainSubstring("allowPrivilegeEscalation"),
+				ContainSubstring("capabilities.drop"),
+				ContainSubstring("runAsNonRoot"),
+				ContainSubstring("seccompProfile"),
+			))
+
+			b.Spec.SecurityMode = perfv1.SecurityAuto
+			Expect(podSecurityViolations("restricted", podSpec(b))).Should(BeEmpty())
+
+			b.Spec.CustomArgs = []string{"--chown", "1"}
+			Expect(podSecurityViolations("restricted", podSpec(b))).Should(ConsistOf(ContainSubstring("CHOWN"), ContainSubstring("runAsNonRoot")))
+		})
+
+		It("Should reject the hostPath scratch volumes", func() {
+			b := &perfv1.Baseline{Spec: perfv1.BaselineSpec{
+				Workload:      perfv1.WorkloadStressNG,
+				SecurityMode:  perfv1.SecurityAuto,
+				Hdd:           1,
+				ScratchVolume: &perfv1.ScratchVolume{Type: perfv1.ScratchHostPath, Path: "/var/lib/baseline"},
+			}}
+			Expect(podSecurityViolations("baseline", podSpec(b))).Should(ConsistOf(ContainSubstring("hostPath")))
+		})
+
+		It("Should check the init containers added by the pod template", func() {
+			b := &perfv1.Baseline{Spec: perfv1.BaselineSpec{
+				Workload:     perfv1.WorkloadStressNG,
+				SecurityMode: perfv1.SecurityAuto,
+				PodTemplate: &runtime.RawExtension{Raw: []byte(
+					`{"spec":{"initContainers":[{"name":"setup","image":"busybox","securityContext":{"privileged":true}}]}}`)},
+			}}
+			Expect(podSecurityViolations("baseline", podSpec(b))).Should(ConsistOf(ContainSubstring(`container "setup"`)))
+		})
+
+		It("Should reject the unsafe sysctls, AppArmor profiles and SELinux options in the baseline level", func() {
+			b := &perfv1.Baseline{Spec: perfv1.BaselineSpec{
+				Workload:     perfv1.WorkloadStressNG,
+				SecurityMode: perfv1.SecurityAuto,
+				PodTemplate: &runtime.RawExtension{Raw: []byte(`{
+					"metadata":{"annotations":{"container.apparmor.security.beta.kubernetes.io/stress-ng":"unconfined"}},
+					"spec":{"securityContext":{
+						"sysctls":[{"name":"net.ipv4.tcp_syncookies","value":"1"},{"name":"kernel.msgmax","value":"65536"}],
+						"seLinuxOptions":{"type":"spc_t"}}}}`)},
+			}}
+			Expect(podSecurityViolations("baseline", podSpec(b))).Should(ConsistOf(
+				ContainSubstring("kernel.msgmax"),
+				ContainSubstring("AppArmor"),
+				ContainSubstring("spc_t"),
+			))
+		})
+	})
+
+	Context("Evaluating the churn pods", func() {
+		It("Should reject the churn pods missing the restricted fields", func() {
+			b := &perfv1.Baseline{Spec: perfv1.BaselineSpec{Workload: perfv1.WorkloadChurn}}
+			pod := r.churnPod(b, nil)
+			template := &corev1.PodTemplateSpec{ObjectMeta: pod.ObjectMeta, Spec: pod.Spec}
+			Expect(podSecurityViolations("baseline", template)).Should(BeEmpty())
+			Expect(podSecurityViolations("restricted", template)).ShouldNot(BeEmpty())
+		})
+	})
+
+	Context("Evaluating the pods of the Deployments and the image probe", func() {
+		It("Should render the pod templates of every workload", func() {
+			b := &perfv1.Baseline{Spec: perfv1.BaselineSpec{Workload: perfv1.WorkloadHTTP}}
+			b.Name, b.Namespace = "baseline-sample", "default"
+			namespace, templates := r.podTemplatesForBaseline(b)
+			Expect(namespace).Should(Equal("default"))
+			Expect(templates).Should(HaveLen(2))
+			Expect(podSecurityViolations("restricted", templates[0])).ShouldNot(BeEmpty())
+
+			b.Spec.Workload = perfv1.WorkloadIperf3
+			_, templates = r.podTemplatesForBaseline(b)
+			Expect(templates).Should(HaveLen(2))
+
+			b.Spec.Workload = perfv1.WorkloadAPIServer
+			namespace, templates = r.podTemplatesForBaseline(b)
+			Expect(namespace).Should(Equal(apiServerSpec(b).Namespace))
+			Expect(templates).Should(HaveLen(1))
+		})
+
+		It("Should admit the image probe Job in the restricted level", func() {
+			r.ProbeImages = true
+			r.KubeClient = fake.NewSimpleClientset()
+			b := &perfv1.Baseline{Spec: perfv1.BaselineSpec{
+				Workload:     perfv1.WorkloadStressNG,
+				Image:        "quay.io/example/stress-ng:custom",
+				SecurityMode: perfv1.SecurityAuto,
+			}}
+			_, templates := r.podTemplatesForBaseline(b)
+			Expect(templates).Should(HaveLen(2))
+			Expect(templates[1].Spec.Containers[0].Name).Should(Equal("probe"))
+			Expect(podSecurityViolations("restricted", templates[1])).Should(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,99 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+)
+
+// podTemplateHashAnnotation records the pod template overlay applied to the
+// stress pods, so its changes are rolled out
+const podTemplateHashAnnotation = "perf.baseline.io/pod-template-hash"
+
+// applyPodTemplate strategic-merges the pod template overlay of the Baseline
+// over the rendered template. The selector labels are kept, as the pods
+// would otherwise not be selected
+func applyPodTemplate(template *corev1.PodTemplateSpec, overlay *runtime.RawExtension, selector map[string]string) error {
+	if overlay == nil || len(overlay.Raw) == 0 {
+		return nil
+	}
+	result, err := mergePodTemplate(template, overlay, selector)
+	if err != nil {
+		return err
+	}
+	if result.Annotations == nil {
+		result.Annotations = map[string]string{}
+	}
+	hash := fnv.New32a()
+	hash.Write(overlay.Raw)
+	result.Annotations[podTemplateHashAnnotation] = strconv.FormatUint(uint64(hash.Sum32()), 16)
+	*template = result
+	return nil
+}
+
+// mergePodTemplate returns the pod template overlay strategic-merged over
+// the template, keeping the selector labels
+func mergePodTemplate(template *corev1.PodTemplateSpec, overlay *runtime.RawExtension, selector map[string]string) (corev1.PodTemplateSpec, error) {
+	result := corev1.PodTemplateSpec{}
+	original, err := json.Marshal(template)
+	if err != nil {
+		return result, err
+	}
+	merged, err := strategicpatch.StrategicMergePatch(original, overlay.Raw, corev1.PodTemplateSpec{})
+	if err != nil {
+		return result, fmt.Errorf("invalid podTemplate: %w", err)
+	}
+	if err := json.Unmarshal(merged, &result); err != nil {
+		return result, fmt.Errorf("invalid podTemplate: %w", err)
+	}
+
+	if result.Labels == nil {
+		result.Labels = map[string]string{}
+	}
+	for k, v := range selector {
+		result.Labels[k] = v
+	}
+	return result, nil
+}
+
+// podTemplateChanged returns if the fields set by the operator or the pod
+// template overlay differ between the templates. The fields set by the
+// overlay, i.e. the env vars, volumes, annotations or labels, are compared
+// by merging the overlay again over the found template: it changes nothing
+// unless they were edited
+func podTemplateChanged(found, desired *corev1.PodTemplateSpec, overlay *runtime.RawExtension, selector map[string]string) bool {
+	if podSpecChanged(&found.Spec, &desired.Spec) ||
+		found.Annotations[podTemplateHashAnnotation] != desired.Annotations[podTemplateHashAnnotation] {
+		return true
+	}
+	if overlay == nil || len(overlay.Raw) == 0 {
+		return false
+	}
+	merged, err := mergePodTemplate(found, overlay, selector)
+	if err != nil {
+		return true
+	}
+	return !equality.Semantic.DeepEqual(&merged, found)
+}
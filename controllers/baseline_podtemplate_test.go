@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	perfv1 "github.com/josecastillolema/baseline-operator/api/v1"
+)
+
+var _ = Describe("Pod template overlays", func() {
+
+	var r *BaselineReconciler
+
+	BeforeEach(func() {
+		r = &BaselineReconciler{Scheme: scheme.Scheme}
+	})
+
+	Context("Merging the overlay over the stress pods", func() {
+		It("Should add the annotations, labels, env vars and volumes", func() {
+			cpu := int32(1)
+			b := &perfv1.Baseline{Spec: perfv1.BaselineSpec{Cpu: &cpu, PodTemplate: &runtime.RawExtension{Raw: []byte(`{
+				"metadata": {"annotations": {"sidecar.istio.io/inject": "false"}, "labels": {"team": "perf", "app": "other"}},
+				"spec": {
+					"imagePullSecrets": [{"name": "registry"}],
+					"containers": [{"name": "stressng", "env": [{"name": "FOO", "value": "bar"}], "volumeMounts": [{"name": "extra", "mountPath": "/extra"}]}],
+					"volumes": [{"name": "extra", "configMap": {"name": "extra"}}]
+				}
+			}`)}}}
+			b.Name = "baseline-sample"
+			ds, err := r.daemonsetForBaseline(b, nil)
+			Expect(err).ShouldNot(HaveOccurred())
+			template := ds.Spec.Template
+			Expect(template.Annotations).Should(HaveKeyWithValue("sidecar.istio.io/inject", "false"))
+			Expect(template.Annotations).Should(HaveKey(podTemplateHashAnnotation))
+			Expect(template.Labels).Should(HaveKeyWithValue("team", "perf"))
+			Expect(template.Labels).Should(HaveKeyWithValue("app", "baseline"))
+			Expect(template.Spec.ImagePullSecrets).Should(Equal([]corev1.LocalObjectReference{{Name: "registry"}}))
+			Expect(template.Spec.Containers).Should(HaveLen(1))
+			container := template.Spec.Containers[0]
+			Expect(container.Args).Should(Equal([]string{"stress-ng", "-t", "0", "--cpu", "1"}))
+			Expect(container.Env).Should(Equal([]corev1.EnvVar{{Name: "FOO", Value: "bar"}}))
+			Expect(container.VolumeMounts).Should(ContainElement(HaveField("MountPath", "/extra")))
+			Expect(template.Spec.Volumes).Should(ContainElement(HaveField("Name", "extra")))
+		})
+
+		It("Should roll out the changes of the overlay", func() {
+			b := &perfv1.Baseline{Spec: perfv1.BaselineSpec{PodTemplate: &runtime.RawExtension{Raw: []byte(`{"metadata":{"annotations":{"a":"1"}}}`)}}}
+			found, err := r.daemonsetForBaseline(b, nil)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(podTemplateChanged(&found.Spec.Template, &found.Spec.Template, b.Spec.PodTemplate, found.Spec.Selector.MatchLabels)).Should(BeFalse())
+
+			b.Spec.PodTemplate.Raw = []byte(`{"metadata":{"annotations":{"a":"2"}}}`)
+			desired, err := r.daemonsetForBaseline(b, nil)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(podTemplateChanged(&found.Spec.Template, &desired.Spec.Template, b.Spec.PodTemplate, desired.Spec.Selector.MatchLabels)).Should(BeTrue())
+		})
+
+		It("Should revert the manual edits of the fields set by the overlay", func() {
+			b := &perfv1.Baseline{Spec: perfv1.BaselineSpec{PodTemplate: &runtime.RawExtension{Raw: []byte(`{
+				"metadata": {"annotations": {"a": "1"}, "labels": {"team": "perf", "app": "other"}},
+				"spec": {
+					"containers": [{"name": "stressng", "env": [{"name": "FOO", "value": "bar"}]}],
+					"volumes": [{"name": "extra", "configMap": {"name": "extra"}}]
+				}
+			}`)}}}
+			b.Name = "baseline-sample"
+			desired, err := r.daemonsetForBaseline(b, nil)
+			Expect(err).ShouldNot(HaveOccurred())
+			changed := func(edit func(*corev1.PodTemplateSpec)) bool {
+				found := desired.Spec.Template.DeepCopy()
+				edit(found)
+				return podTemplateChanged(found, &desired.Spec.Template, b.Spec.PodTemplate, desired.Spec.Selector.MatchLabels)
+			}
+
+			Expect(changed(func(t *corev1.PodTemplateSpec) {})).Should(BeFalse())
+			// The fields not set by the overlay may be edited by others
+			Expect(changed(func(t *corev1.PodTemplateSpec) { t.Annotations["restartedAt"] = "now" })).Should(BeFalse())
+			Expect(changed(func(t *corev1.PodTemplateSpec) { t.Spec.Containers[0].Env[0].Value = "baz" })).Should(BeTrue())
+			Expect(changed(func(t *corev1.PodTemplateSpec) { delete(t.Annotations, "a") })).Should(BeTrue())
+			Expect(changed(func(t *corev1.PodTemplateSpec) { t.Labels["team"] = "other" })).Should(BeTrue())
+		})
+
+		It("Should reject an invalid overlay", func() {
+			b := &perfv1.Baseline{Spec: perfv1.BaselineSpec{PodTemplate: &runtime.RawExtension{Raw: []byte(`{"spec":{"containers":"stressng"}}`)}}}
+			_, err := r.daemonsetForBaseline(b, nil)
+			Expect(err).Should(HaveOccurred())
+		})
+	})
+})
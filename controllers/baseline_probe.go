@@ -0,0 +1,331 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	perfv1 "github.com/josecastillolema/baseline-operator/api/v1"
+)
+
+const (
+	// imageProbesName is the name of the ConfigMap caching the probes of
+	// the stress-ng images of a namespace
+	imageProbesName = "stress-ng-probes"
+	// imageProbeRequeue is how often a running probe is checked
+	imageProbeRequeue = 5 * time.Second
+	// imageProbeDeadline is how long a probe can run before failing
+	imageProbeDeadline = 120
+	// imageProbeRetry is how long the failure of a probe Job is cached
+	// before probing the image again, as the failure may be transient
+	imageProbeRetry = 10 * time.Minute
+	// probeVersionPrefix prefixes the probe log line holding the version
+	probeVersionPrefix = "PROBE_VERSION "
+	// probeStressorsPrefix prefixes the probe log line holding the stressors
+	probeStressorsPrefix = "PROBE_STRESSORS "
+)
+
+// probeScript prints the version, the stressors and the options of the
+// stress-ng image
+const probeScript = `echo "` + probeVersionPrefix + `$(stress-ng --version)"
+echo "` + probeStressorsPrefix + `$(stress-ng --stressors)"
+stress-ng --help
+`
+
+var (
+	// probeVersionRegexp matches the version in stress-ng --version
+	probeVersionRegexp = regexp.MustCompile(`version ([0-9][0-9.]*)`)
+	// probeOptionRegexp matches the long options in stress-ng --help
+	probeOptionRegexp = regexp.MustCompile(`(?:^|[\s,])--([a-z0-9][a-z0-9-]*)`)
+)
+
+// imageProbe is what a stress-ng image was probed to support
+type imageProbe struct {
+	Image     string   `json:"image"`
+	Version   string   `json:"version,omitempty"`
+	Stressors []string `json:"stressors,omitempty"`
+	Options   []string `json:"options,omitempty"`
+	// Error is why the image could not be probed, its flags are then not
+	// checked
+	Error string `json:"error,omitempty"`
+	// FailedAt is when the probe Job failed, the image is probed again
+	// once the failure is older than imageProbeRetry
+	FailedAt *metav1.Time `json:"failedAt,omitempty"`
+}
+
+// retryAfter returns how long until the image of a failed probe Job is
+// probed again, or 0 if the probe is final
+func (p *imageProbe) retryAfter(now time.Time) time.Duration {
+	if p == nil || p.FailedAt == nil {
+		return 0
+	}
+	if left := imageProbeRetry - now.Sub(p.FailedAt.Time); left > 0 {
+		return left
+	}
+	return imageProbeRequeue
+}
+
+// imageProbeKey returns the key of the image in the probes ConfigMap and
+// the name of its probe Job, as images are not valid keys
+func imageProbeKey(image string) string {
+	hash := fnv.New32a()
+	hash.Write([]byte(image))
+	return "stress-ng-probe-" + strconv.FormatUint(uint64(hash.Sum32()), 16)
+}
+
+// parseImageProbe returns the version, the stressors and the options found
+// in the logs of a probe
+func parseImageProbe(image string, logs []byte) *imageProbe {
+	probe := &imageProbe{Image: image}
+	options := map[string]bool{}
+	scanner := bufio.NewScanner(bytes.NewReader(logs))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, probeVersionPrefix):
+			if m := probeVersionRegexp.FindStringSubmatch(line); m != nil {
+				probe.Version = m[1]
+			}
+		case strings.HasPrefix(line, probeStressorsPrefix):
+			probe.Stressors = strings.Fields(strings.TrimPrefix(line, probeStressorsPrefix))
+		default:
+			for _, m := range probeOptionRegexp.FindAllStringSubmatch(line, -1) {
+				options[m[1]] = true
+			}
+		}
+	}
+	for option := range options {
+		probe.Options = append(probe.Options, option)
+	}
+	sort.Strings(probe.Options)
+	if probe.Version == "" || len(probe.Options) == 0 {
+		probe.Error = "stress-ng not found in the image"
+	}
+	return probe
+}
+
+// unsupportedFlags returns the stress-ng flags of the command the image was
+// not probed to support
+func (p *imageProbe) unsupportedFlags(command []string) []string {
+	if p.Error != "" || len(p.Options) == 0 {
+		return nil
+	}
+	supported := map[string]bool{}
+	for _, s := range append(p.Stressors, p.Options...) {
+		supported[s] = true
+	}
+	var unsupported []string
+	// Skip the numactl prefix of the command
+	for i, arg := range command {
+		if arg == "stress-ng" {
+			command = command[i+1:]
+			break
+		}
+	}
+	for _, arg := range command {
+		if !strings.HasPrefix(arg, "--") {
+			continue
+		}
+		name := strings.SplitN(strings.TrimPrefix(arg, "--"), "=", 2)[0]
+		if !supported[name] {
+			unsupported = append(unsupported, "--"+name)
+		}
+	}
+	return unsupported
+}
+
+// check returns an error if the Baseline uses flags the image does not
+// support
+func (p *imageProbe) check(b *perfv1.Baseline) error {
+	if b.Spec.Workload != perfv1.WorkloadStressNG {
+		return nil
+	}
+	command, err := commandForBaseline(b)
+	if err != nil {
+		// The invalid spec is reported when creating the daemonset
+		return nil
+	}
+	if unsupported := p.unsupportedFlags(command); len(unsupported) > 0 {
+		return fmt.Errorf("image %s (stress-ng %s) does not support %s", p.Image, p.Version, strings.Join(unsupported, ", "))
+	}
+	return nil
+}
+
+// imageProbeJob returns the Job probing the stress-ng image of the Baseline
+func (r *BaselineReconciler) imageProbeJob(b *perfv1.Baseline) *batchv1.Job {
+	backoffLimit := int32(0)
+	deadline := int64(imageProbeDeadline)
+	sc := autoSecurityContext(nil, nil)
+	volume, mount := tmpVolume()
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      imageProbeKey(b.Spec.Image),
+			Namespace: b.Namespace,
+			Labels:    map[string]string{"app": "baseline-probe"},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:          &backoffLimit,
+			ActiveDeadlineSeconds: &deadline,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{"app": "baseline-probe"},
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy:    corev1.RestartPolicyNever,
+					NodeSelector:     b.Spec.NodeSelector,
+					Tolerations:      b.Spec.Tolerations,
+					ImagePullSecrets: b.Spec.ImagePullSecrets,
+					Containers: []corev1.Container{{
+						Name:            "probe",
+						Image:           b.Spec.Image,
+						ImagePullPolicy: b.Spec.ImagePullPolicy,
+						Command:         []string{"/bin/sh", "-c", probeScript},
+						SecurityContext: sc,
+						WorkingDir:      workingDir(sc),
+						VolumeMounts:    []corev1.VolumeMount{mount},
+					}},
+					Volumes: []corev1.Volume{volume},
+				},
+			},
+		},
+	}
+	// The Baseline that started the probe owns it, so it is not left behind
+	ctrl.SetControllerReference(b, job, r.Scheme)
+	return job
+}
+
+// probeImage returns what the stress-ng image of the Baseline supports. It
+// is taken from the probes cached in the namespace, or nil while the probe
+// Job of the image runs
+func (r *BaselineReconciler) probeImage(ctx context.Context, b *perfv1.Baseline) (*imageProbe, error) {
+	log := ctrllog.FromContext(ctx)
+	if !r.ProbeImages || r.KubeClient == nil || b.Spec.Workload != perfv1.WorkloadStressNG {
+		return &imageProbe{Image: b.Spec.Image}, nil
+	}
+	key := imageProbeKey(b.Spec.Image)
+
+	cache := &corev1.ConfigMap{}
+	err := r.Get(ctx, types.NamespacedName{Name: imageProbesName, Namespace: b.Namespace}, cache)
+	if err != nil && !errors.IsNotFound(err) {
+		log.Error(err, "Failed to get the image probes", "ConfigMap.Namespace", b.Namespace, "ConfigMap.Name", imageProbesName)
+		return nil, err
+	}
+	if data, ok := cache.Data[key]; ok {
+		probe := &imageProbe{}
+		// The failed probe Jobs are run again after a while
+		if err := json.Unmarshal([]byte(data), probe); err == nil && probe.Image == b.Spec.Image &&
+			(probe.FailedAt == nil || time.Since(probe.FailedAt.Time) < imageProbeRetry) {
+			return probe, nil
+		}
+	}
+
+	job := &batchv1.Job{}
+	err = r.Get(ctx, types.NamespacedName{Name: key, Namespace: b.Namespace}, job)
+	if err != nil && errors.IsNotFound(err) {
+		job = r.imageProbeJob(b)
+		log.Info("Probing the stress-ng image", "Job.Namespace", job.Namespace, "Job.Name", job.Name, "Image", b.Spec.Image)
+		err = r.Create(ctx, job)
+		if err != nil && !errors.IsAlreadyExists(err) {
+			log.Error(err, "Failed to create the image probe", "Job.Namespace", job.Namespace, "Job.Name", job.Name)
+			return nil, err
+		}
+		return nil, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get the image probe", "Job.Namespace", b.Namespace, "Job.Name", key)
+		return nil, err
+	}
+
+	var probe *imageProbe
+	if job.Status.Succeeded > 0 {
+		pods := &corev1.PodList{}
+		err = r.List(ctx, pods, client.InNamespace(job.Namespace), client.MatchingLabels{"job-name": job.Name})
+		if err != nil {
+			log.Error(err, "Failed to list the image probe pods")
+			return nil, err
+		}
+		for _, pod := range pods.Items {
+			if pod.Status.Phase != corev1.PodSucceeded {
+				continue
+			}
+			logs, err := r.KubeClient.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{}).DoRaw(ctx)
+			if err != nil {
+				log.Error(err, "Failed to get the image probe logs", "Pod.Namespace", pod.Namespace, "Pod.Name", pod.Name)
+				return nil, err
+			}
+			probe = parseImageProbe(b.Spec.Image, logs)
+			break
+		}
+	} else if job.Status.Failed > 0 {
+		now := metav1.Now()
+		probe = &imageProbe{Image: b.Spec.Image, Error: "the probe Job failed", FailedAt: &now}
+	}
+	if probe == nil {
+		return nil, nil
+	}
+	if probe.Error != "" {
+		r.recorder.Event(b, "Warning", "ImageProbeFailed", fmt.Sprintf("Failed to probe image %s, its flags are not checked: %s", b.Spec.Image, probe.Error))
+	} else {
+		r.recorder.Event(b, "Normal", "ImageProbed", fmt.Sprintf("Probed image %s: stress-ng %s with %d stressors", b.Spec.Image, probe.Version, len(probe.Stressors)))
+	}
+
+	data, err := json.Marshal(probe)
+	if err != nil {
+		return nil, err
+	}
+	if cache.Name == "" {
+		cache = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: imageProbesName, Namespace: b.Namespace},
+			Data:       map[string]string{key: string(data)},
+		}
+		err = r.Create(ctx, cache)
+	} else {
+		if cache.Data == nil {
+			cache.Data = map[string]string{}
+		}
+		cache.Data[key] = string(data)
+		err = r.Update(ctx, cache)
+	}
+	if err != nil {
+		log.Error(err, "Failed to cache the image probe", "ConfigMap.Namespace", b.Namespace, "ConfigMap.Name", imageProbesName)
+		return nil, err
+	}
+	err = r.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground))
+	if err != nil && !errors.IsNotFound(err) {
+		log.Error(err, "Failed to delete the image probe", "Job.Namespace", job.Namespace, "Job.Name", job.Name)
+		return nil, err
+	}
+	return probe, nil
+}
@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	perfv1 "github.com/josecastillolema/baseline-operator/api/v1"
+)
+
+var _ = Describe("Image probes", func() {
+
+	const logs = `PROBE_VERSION stress-ng, version 0.13.05 (gcc 8.5, x86_64 Linux 5.14.0)
+PROBE_STRESSORS cpu io sock vm hdd
+Example: stress-ng --cpu 8 --io 4 --vm 2 --vm-bytes 128M --fork 4 --timeout 10s
+
+General control options:
+      --metrics-brief       enable metrics and only show non-zero results
+      --temp-path path      specify path for temporary directories and files
+  -t N, --timeout T         timeout after T seconds
+
+Stressor specific options:
+  -c N, --cpu N             start N workers that perform CPU only loading
+      --vm-bytes N          allocate N bytes per vm worker (default 256MB)
+`
+
+	Context("Parsing the probe logs", func() {
+		It("Should record the version, the stressors and the options", func() {
+			probe := parseImageProbe("registry.local/stressng:0.13.05", []byte(logs))
+			Expect(probe.Error).Should(BeEmpty())
+			Expect(probe.Version).Should(Equal("0.13.05"))
+			Expect(probe.Stressors).Should(Equal([]string{"cpu", "io", "sock", "vm", "hdd"}))
+			Expect(probe.Options).Should(ContainElements("metrics-brief", "temp-path", "timeout", "vm-bytes"))
+		})
+
+		It("Should flag the images without stress-ng", func() {
+			probe := parseImageProbe("registry.local/busybox", []byte("PROBE_VERSION \nPROBE_STRESSORS \n"))
+			Expect(probe.Error).ShouldNot(BeEmpty())
+			Expect(probe.unsupportedFlags([]string{"stress-ng", "--sock-if", "eth0"})).Should(BeEmpty())
+		})
+	})
+
+	Context("Checking the Baselines against the probe", func() {
+		It("Should reject the flags the image does not support", func() {
+			probe := parseImageProbe("registry.local/stressng:0.13.05", []byte(logs))
+			Expect(probe.unsupportedFlags([]string{"numactl", "--membind=0", "--", "stress-ng", "--cpu", "1", "--vm-bytes=1G"})).Should(BeEmpty())
+
+			cpu, sock := int32(1), int32(1)
+			b := &perfv1.Baseline{Spec: perfv1.BaselineSpec{
+				Workload:      perfv1.WorkloadStressNG,
+				Image:         "registry.local/stressng:0.13.05",
+				Cpu:           &cpu,
+				Sock:          &sock,
+				SockInterface: "eth0",
+			}}
+			err := probe.check(b)
+			Expect(err).Should(HaveOccurred())
+			Expect(err.Error()).Should(Equal("image registry.local/stressng:0.13.05 (stress-ng 0.13.05) does not support --sock-if"))
+
+			b.Spec.Sock = nil
+			Expect(probe.check(b)).Should(Succeed())
+		})
+	})
+
+	Context("Caching the probes", func() {
+		It("Should key them by image", func() {
+			Expect(imageProbeKey("quay.io/a/stressng:1")).ShouldNot(Equal(imageProbeKey("quay.io/a/stressng:2")))
+			Expect(imageProbeKey("quay.io/a/stressng:1")).Should(MatchRegexp(`^[a-z0-9-]+$`))
+		})
+
+		It("Should probe the image again after a failed probe Job", func() {
+			now := time.Now()
+			Expect((&imageProbe{Error: "stress-ng not found in the image"}).retryAfter(now)).Should(BeZero())
+
+			failedAt := metav1.NewTime(now.Add(-time.Minute))
+			probe := &imageProbe{Error: "the probe Job failed", FailedAt: &failedAt}
+			Expect(probe.retryAfter(now)).Should(Equal(imageProbeRetry - time.Minute))
+			Expect(probe.retryAfter(now.Add(imageProbeRetry))).Should(Equal(imageProbeRequeue))
+		})
+	})
+})
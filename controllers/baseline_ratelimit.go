@@ -0,0 +1,70 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/ratelimiter"
+)
+
+// NewRateLimiter returns the rate limiter of the reconcile requests: the
+// failures of a Baseline are retried with an exponential backoff between
+// the base and the max delays, and all the requests share a qps budget
+func NewRateLimiter(baseDelay, maxDelay time.Duration, qps float64, burst int) (ratelimiter.RateLimiter, error) {
+	if baseDelay <= 0 || maxDelay < baseDelay {
+		return nil, fmt.Errorf("the base delay %s must be positive and lower than the max delay %s", baseDelay, maxDelay)
+	}
+	if qps <= 0 || burst < 1 {
+		return nil, fmt.Errorf("the qps %v must be positive and the burst %d at least 1", qps, burst)
+	}
+	return workqueue.NewMaxOfRateLimiter(
+		workqueue.NewItemExponentialFailureRateLimiter(baseDelay, maxDelay),
+		&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(qps), burst)},
+	), nil
+}
+
+// NewDaemonSetCreateLimiter returns the limiter spreading the DaemonSet
+// creations of the operator to the given number per minute, or nil if
+// they are not limited
+func NewDaemonSetCreateLimiter(perMinute int) *rate.Limiter {
+	if perMinute <= 0 {
+		return nil
+	}
+	return rate.NewLimiter(rate.Every(time.Minute/time.Duration(perMinute)), 1)
+}
+
+// reserveDaemonSetCreate returns how long a DaemonSet creation must wait for
+// the creation budget of the operator. The creation is accounted when it
+// can happen now, and the returned function gives it back to the budget
+// when it then fails
+func (r *BaselineReconciler) reserveDaemonSetCreate() (time.Duration, func()) {
+	if r.DaemonSetCreates == nil {
+		return 0, func() {}
+	}
+	now := time.Now()
+	reservation := r.DaemonSetCreates.ReserveN(now, 1)
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		return delay, func() {}
+	}
+	// A reservation is only given back as of the time it was made
+	return 0, func() { reservation.CancelAt(now) }
+}
@@ -0,0 +1,65 @@
+package controllers
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+var _ = Describe("Rate limiting", func() {
+
+	Context("Building the reconcile rate limiter", func() {
+		It("Should back off the failures of a Baseline", func() {
+			limiter, err := NewRateLimiter(10*time.Millisecond, time.Second, 10, 100)
+			Expect(err).ShouldNot(HaveOccurred())
+			item := ctrl.Request{}
+			Expect(limiter.When(item)).Should(Equal(10 * time.Millisecond))
+			Expect(limiter.When(item)).Should(Equal(20 * time.Millisecond))
+			limiter.Forget(item)
+			Expect(limiter.When(item)).Should(Equal(10 * time.Millisecond))
+		})
+
+		It("Should reject the invalid settings", func() {
+			_, err := NewRateLimiter(time.Second, time.Millisecond, 10, 100)
+			Expect(err).Should(HaveOccurred())
+			_, err = NewRateLimiter(time.Millisecond, time.Second, 0, 100)
+			Expect(err).Should(HaveOccurred())
+			_, err = NewRateLimiter(time.Millisecond, time.Second, 10, 0)
+			Expect(err).Should(HaveOccurred())
+		})
+	})
+
+	Context("Creating the DaemonSets", func() {
+		It("Should not delay them when unlimited", func() {
+			r := &BaselineReconciler{DaemonSetCreates: NewDaemonSetCreateLimiter(0)}
+			Expect(r.DaemonSetCreates).Should(BeNil())
+			delay, _ := r.reserveDaemonSetCreate()
+			Expect(delay).Should(BeZero())
+		})
+
+		It("Should spread them over the minute", func() {
+			r := &BaselineReconciler{DaemonSetCreates: NewDaemonSetCreateLimiter(2)}
+			delay, _ := r.reserveDaemonSetCreate()
+			Expect(delay).Should(BeZero())
+			delay, _ = r.reserveDaemonSetCreate()
+			Expect(delay).Should(BeNumerically(">", 29*time.Second))
+			Expect(delay).Should(BeNumerically("<=", 30*time.Second))
+			// A delayed creation does not consume the budget
+			next, _ := r.reserveDaemonSetCreate()
+			Expect(next).Should(BeNumerically("~", delay, time.Second))
+		})
+
+		It("Should give a failed creation back to the budget", func() {
+			r := &BaselineReconciler{DaemonSetCreates: NewDaemonSetCreateLimiter(2)}
+			delay, cancelCreate := r.reserveDaemonSetCreate()
+			Expect(delay).Should(BeZero())
+			cancelCreate()
+			delay, _ = r.reserveDaemonSetCreate()
+			Expect(delay).Should(BeZero())
+			delay, _ = r.reserveDaemonSetCreate()
+			Expect(delay).Should(BeNumerically(">", 29*time.Second))
+		})
+	})
+})
@@ -0,0 +1,87 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	perfv1 "github.com/josecastillolema/baseline-operator/api/v1"
+)
+
+const (
+	// scratchVolumeName is the name of the volume the hdd workers write to
+	scratchVolumeName = "scratch"
+	// scratchPath is where the scratch volume is mounted, passed to
+	// stress-ng as --temp-path
+	scratchPath = "/scratch"
+)
+
+// usesScratch returns if the Baseline mounts a scratch volume
+func usesScratch(b *perfv1.Baseline) bool {
+	return b.Spec.Hdd > 0 || b.Spec.ScratchVolume != nil || b.Spec.Workload == perfv1.WorkloadFio
+}
+
+// scratchVolume returns the scratch volume of the Baseline and its mount,
+// or nil if it does not use one
+func scratchVolume(b *perfv1.Baseline) ([]corev1.Volume, []corev1.VolumeMount, error) {
+	if !usesScratch(b) {
+		return nil, nil, nil
+	}
+	spec := b.Spec.ScratchVolume
+	if spec == nil {
+		spec = &perfv1.ScratchVolume{}
+	}
+
+	volume := corev1.Volume{Name: scratchVolumeName}
+	switch spec.Type {
+	case perfv1.ScratchEmptyDir, "":
+		volume.EmptyDir = &corev1.EmptyDirVolumeSource{SizeLimit: spec.Size}
+	case perfv1.ScratchHostPath:
+		if spec.Path == "" {
+			return nil, nil, fmt.Errorf("scratchVolume of type HostPath requires a path")
+		}
+		hostPathType := corev1.HostPathDirectoryOrCreate
+		volume.HostPath = &corev1.HostPathVolumeSource{Path: spec.Path, Type: &hostPathType}
+	case perfv1.ScratchEphemeral:
+		if spec.Size == nil {
+			return nil, nil, fmt.Errorf("scratchVolume of type Ephemeral requires a size")
+		}
+		volumeMode := corev1.PersistentVolumeFilesystem
+		volume.Ephemeral = &corev1.EphemeralVolumeSource{
+			VolumeClaimTemplate: &corev1.PersistentVolumeClaimTemplate{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labelsForBaseline(b.Name),
+				},
+				Spec: corev1.PersistentVolumeClaimSpec{
+					AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+					StorageClassName: spec.StorageClassName,
+					VolumeMode:       &volumeMode,
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceStorage: *spec.Size},
+					},
+				},
+			},
+		}
+	default:
+		return nil, nil, fmt.Errorf("unknown scratchVolume type %s", spec.Type)
+	}
+	mount := corev1.VolumeMount{Name: scratchVolumeName, MountPath: scratchPath}
+	return []corev1.Volume{volume}, []corev1.VolumeMount{mount}, nil
+}
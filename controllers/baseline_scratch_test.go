@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	perfv1 "github.com/josecastillolema/baseline-operator/api/v1"
+)
+
+var _ = Describe("Scratch volumes", func() {
+
+	Context("Running hdd workers", func() {
+		It("Should write to an emptyDir by default", func() {
+			b := &perfv1.Baseline{Spec: perfv1.BaselineSpec{Hdd: 2, HddBytes: "1G", HddWriteSize: "4k"}}
+			command, err := commandForBaseline(b)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(command).Should(Equal([]string{"stress-ng", "-t", "0",
+				"--hdd", "2", "--hdd-bytes", "1G", "--hdd-write-size", "4k", "--temp-path", "/scratch"}))
+
+			volumes, mounts, err := scratchVolume(b)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(volumes).Should(HaveLen(1))
+			Expect(volumes[0].EmptyDir).ShouldNot(BeNil())
+			Expect(mounts[0].MountPath).Should(Equal("/scratch"))
+		})
+
+		It("Should provision an ephemeral volume from the StorageClass", func() {
+			size := resource.MustParse("10Gi")
+			storageClass := "fast"
+			b := &perfv1.Baseline{Spec: perfv1.BaselineSpec{Hdd: 1, ScratchVolume: &perfv1.ScratchVolume{
+				Type:             perfv1.ScratchEphemeral,
+				Size:             &size,
+				StorageClassName: &storageClass,
+			}}}
+			volumes, _, err := scratchVolume(b)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(*volumes[0].Ephemeral.VolumeClaimTemplate.Spec.StorageClassName).Should(Equal("fast"))
+
+			b.Spec.ScratchVolume.Size = nil
+			_, _, err = scratchVolume(b)
+			Expect(err).Should(HaveOccurred())
+		})
+
+		It("Should require a path for a HostPath volume", func() {
+			b := &perfv1.Baseline{Spec: perfv1.BaselineSpec{Hdd: 1, ScratchVolume: &perfv1.ScratchVolume{Type: perfv1.ScratchHostPath}}}
+			_, _, err := scratchVolume(b)
+			Expect(err).Should(HaveOccurred())
+
+			b.Spec.ScratchVolume.Path = "/var/lib/baseline"
+			volumes, _, err := scratchVolume(b)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(volumes[0].HostPath.Path).Should(Equal("/var/lib/baseline"))
+		})
+	})
+})
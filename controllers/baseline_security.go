@@ -0,0 +1,186 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	perfv1 "github.com/josecastillolema/baseline-operator/api/v1"
+)
+
+const (
+	// tmpVolumeName is the name of the writable volume mounted when the root
+	// filesystem is read-only
+	tmpVolumeName = "tmp"
+	// tmpPath is where the tmp volume is mounted, also the working directory
+	// of the containers so stress-ng can create its temporary files
+	tmpPath = "/tmp"
+	// nonRootUser is the user the containers run as in the Auto security
+	// mode when no capability is needed
+	nonRootUser = int64(65532)
+)
+
+// stressorCapabilities are the capabilities the privileged stressors need
+var stressorCapabilities = map[string][]corev1.Capability{
+	"perf":        {"PERFMON", "SYS_ADMIN"},
+	"rawsock":     {"NET_RAW"},
+	"rawpkt":      {"NET_RAW"},
+	"rawudp":      {"NET_RAW"},
+	"icmp-flood":  {"NET_RAW"},
+	"ioport":      {"SYS_RAWIO"},
+	"cpu-online":  {"SYS_ADMIN"},
+	"swap":        {"SYS_ADMIN"},
+	"quota":       {"SYS_ADMIN"},
+	"bind-mount":  {"SYS_ADMIN"},
+	"fanotify":    {"SYS_ADMIN"},
+	"ramfs":       {"SYS_ADMIN"},
+	"loop":        {"SYS_ADMIN"},
+	"klog":        {"SYSLOG"},
+	"mlock":       {"IPC_LOCK"},
+	"mlockmany":   {"IPC_LOCK"},
+	"sched":       {"SYS_NICE"},
+	"schedpolicy": {"SYS_NICE"},
+	"mknod":       {"MKNOD"},
+	"chroot":      {"SYS_CHROOT"},
+	"chown":       {"CHOWN"},
+}
+
+// requiredCapabilities returns the sorted capabilities the stressors of the
+// stress-ng arguments need
+func requiredCapabilities(args []string) []corev1.Capability {
+	set := map[corev1.Capability]bool{}
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "--") {
+			continue
+		}
+		stressor := strings.SplitN(strings.TrimPrefix(arg, "--"), "=", 2)[0]
+		for _, capability := range stressorCapabilities[stressor] {
+			set[capability] = true
+		}
+	}
+	capabilities := make([]corev1.Capability, 0, len(set))
+	for capability := range set {
+		capabilities = append(capabilities, capability)
+	}
+	sort.Slice(capabilities, func(i, j int) bool { return capabilities[i] < capabilities[j] })
+	return capabilities
+}
+
+// securityContextForBaseline returns the security context of the stress
+// containers of the Baseline
+func securityContextForBaseline(b *perfv1.Baseline) (*corev1.SecurityContext, error) {
+	if b.Spec.SecurityMode != perfv1.SecurityAuto {
+		return b.Spec.SecurityContext.DeepCopy(), nil
+	}
+	var args []string
+	if b.Spec.Workload != perfv1.WorkloadFio {
+		var err error
+		args, err = commandForBaseline(b)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return autoSecurityContext(requiredCapabilities(args), b.Spec.SecurityContext), nil
+}
+
+// autoSecurityContext returns a security context adding only the given
+// capabilities, or the restricted Pod Security defaults if there are none.
+// The set fields of the override replace the computed ones
+func autoSecurityContext(capabilities []corev1.Capability, override *corev1.SecurityContext) *corev1.SecurityContext {
+	noEscalation := false
+	sc := &corev1.SecurityContext{
+		AllowPrivilegeEscalation: &noEscalation,
+		Capabilities: &corev1.Capabilities{
+			Add:  capabilities,
+			Drop: []corev1.Capability{"ALL"},
+		},
+		SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+	}
+	if len(capabilities) == 0 {
+		nonRoot, readOnly, user := true, true, nonRootUser
+		sc.Capabilities.Add = nil
+		sc.RunAsNonRoot = &nonRoot
+		sc.RunAsUser = &user
+		sc.ReadOnlyRootFilesystem = &readOnly
+	}
+	if override == nil {
+		return sc
+	}
+	if override.Capabilities != nil {
+		sc.Capabilities = override.Capabilities.DeepCopy()
+	}
+	if override.Privileged != nil {
+		sc.Privileged = override.Privileged
+	}
+	if override.SELinuxOptions != nil {
+		sc.SELinuxOptions = override.SELinuxOptions.DeepCopy()
+	}
+	if override.WindowsOptions != nil {
+		sc.WindowsOptions = override.WindowsOptions.DeepCopy()
+	}
+	if override.RunAsUser != nil {
+		sc.RunAsUser = override.RunAsUser
+	}
+	if override.RunAsGroup != nil {
+		sc.RunAsGroup = override.RunAsGroup
+	}
+	if override.RunAsNonRoot != nil {
+		sc.RunAsNonRoot = override.RunAsNonRoot
+	}
+	if override.ReadOnlyRootFilesystem != nil {
+		sc.ReadOnlyRootFilesystem = override.ReadOnlyRootFilesystem
+	}
+	if override.AllowPrivilegeEscalation != nil {
+		sc.AllowPrivilegeEscalation = override.AllowPrivilegeEscalation
+	}
+	if override.ProcMount != nil {
+		sc.ProcMount = override.ProcMount
+	}
+	if override.SeccompProfile != nil {
+		sc.SeccompProfile = override.SeccompProfile.DeepCopy()
+	}
+	return sc
+}
+
+// readOnlyRoot returns if the security context makes the root filesystem
+// read-only, so the containers need a writable tmp volume
+func readOnlyRoot(sc *corev1.SecurityContext) bool {
+	return sc != nil && sc.ReadOnlyRootFilesystem != nil && *sc.ReadOnlyRootFilesystem
+}
+
+// workingDir returns the working directory of the stress containers
+func workingDir(sc *corev1.SecurityContext) string {
+	if readOnlyRoot(sc) {
+		return tmpPath
+	}
+	return ""
+}
+
+// tmpVolume returns the writable volume mounted at the working directory
+// and its mount
+func tmpVolume() (corev1.Volume, corev1.VolumeMount) {
+	return corev1.Volume{
+		Name:         tmpVolumeName,
+		VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+	}, corev1.VolumeMount{
+		Name:      tmpVolumeName,
+		MountPath: tmpPath,
+	}
+}
@@ -0,0 +1,98 @@
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	perfv1 "github.com/josecastillolema/baseline-operator/api/v1"
+)
+
+var _ = Describe("Security contexts", func() {
+
+	Context("In the Manual security mode", func() {
+		It("Should apply the security context of the spec as is", func() {
+			privileged := true
+			b := &perfv1.Baseline{Spec: perfv1.BaselineSpec{
+				CustomArgs:      []string{"--perf", "1"},
+				SecurityContext: &corev1.SecurityContext{Privileged: &privileged},
+			}}
+			sc, err := securityContextForBaseline(b)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(sc).Should(Equal(&corev1.SecurityContext{Privileged: &privileged}))
+
+			b.Spec.SecurityContext = nil
+			sc, err = securityContextForBaseline(b)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(sc).Should(BeNil())
+		})
+	})
+
+	Context("In the Auto security mode", func() {
+		It("Should add only the capabilities the stressors need", func() {
+			Expect(requiredCapabilities([]string{"stress-ng", "--cpu", "1", "--rawsock", "2", "--perf", "--icmp-flood=1"})).
+				Should(Equal([]corev1.Capability{"NET_RAW", "PERFMON", "SYS_ADMIN"}))
+			Expect(requiredCapabilities([]string{"stress-ng", "--cpu", "1", "--vm", "1"})).Should(BeEmpty())
+
+			b := &perfv1.Baseline{Spec: perfv1.BaselineSpec{
+				SecurityMode: perfv1.SecurityAuto,
+				CustomArgs:   []string{"--mlock", "1"},
+			}}
+			sc, err := securityContextForBaseline(b)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(sc.Capabilities.Add).Should(Equal([]corev1.Capability{"IPC_LOCK"}))
+			Expect(sc.Capabilities.Drop).Should(Equal([]corev1.Capability{"ALL"}))
+			Expect(sc.RunAsNonRoot).Should(BeNil())
+			Expect(readOnlyRoot(sc)).Should(BeFalse())
+		})
+
+		It("Should run restricted pods when no capability is needed", func() {
+			cpu := int32(2)
+			b := &perfv1.Baseline{Spec: perfv1.BaselineSpec{SecurityMode: perfv1.SecurityAuto, Cpu: &cpu}}
+			sc, err := securityContextForBaseline(b)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(sc.Capabilities.Add).Should(BeEmpty())
+			Expect(sc.RunAsNonRoot).Should(HaveValue(BeTrue()))
+			Expect(sc.AllowPrivilegeEscalation).Should(HaveValue(BeFalse()))
+			Expect(sc.SeccompProfile.Type).Should(Equal(corev1.SeccompProfileTypeRuntimeDefault))
+			Expect(readOnlyRoot(sc)).Should(BeTrue())
+
+			r := &BaselineReconciler{Scheme: scheme.Scheme}
+			ds, err := r.daemonsetForBaseline(b, nil)
+			Expect(err).ShouldNot(HaveOccurred())
+			container := ds.Spec.Template.Spec.Containers[0]
+			Expect(container.SecurityContext).Should(Equal(sc))
+			Expect(container.WorkingDir).Should(Equal("/tmp"))
+			Expect(container.VolumeMounts).Should(ContainElement(HaveField("MountPath", "/tmp")))
+			Expect(ds.Spec.Template.Spec.Volumes).Should(ContainElement(HaveField("Name", "tmp")))
+		})
+
+		It("Should let the spec override the computed fields", func() {
+			user := int64(1000)
+			readOnly := false
+			b := &perfv1.Baseline{Spec: perfv1.BaselineSpec{
+				SecurityMode:    perfv1.SecurityAuto,
+				SecurityContext: &corev1.SecurityContext{RunAsUser: &user, ReadOnlyRootFilesystem: &readOnly},
+			}}
+			sc, err := securityContextForBaseline(b)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(sc.RunAsUser).Should(HaveValue(Equal(int64(1000))))
+			Expect(sc.RunAsNonRoot).Should(HaveValue(BeTrue()))
+			Expect(readOnlyRoot(sc)).Should(BeFalse())
+		})
+	})
+
+	Context("Updating the DaemonSet", func() {
+		It("Should detect a new security context", func() {
+			found := &appsv1.DaemonSet{Spec: appsv1.DaemonSetSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "stressng"}},
+			}}}}
+			desired := found.DeepCopy()
+			Expect(podSpecChanged(&found.Spec.Template.Spec, &desired.Spec.Template.Spec)).Should(BeFalse())
+			desired.Spec.Template.Spec.Containers[0].SecurityContext = autoSecurityContext(nil, nil)
+			Expect(podSpecChanged(&found.Spec.Template.Spec, &desired.Spec.Template.Spec)).Should(BeTrue())
+		})
+	})
+})
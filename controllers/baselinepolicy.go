@@ -0,0 +1,141 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	perfv1 "github.com/josecastillolema/baseline-operator/api/v1"
+)
+
+// getPolicy returns the cluster policy, or an empty policy if there is none
+func (r *BaselineReconciler) getPolicy(ctx context.Context) (*perfv1.BaselinePolicy, error) {
+	policy := &perfv1.BaselinePolicy{}
+	err := r.Get(ctx, types.NamespacedName{Name: perfv1.PolicyName}, policy)
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, err
+	}
+	r.applyGuardrails(&policy.Spec)
+	return policy, nil
+}
+
+// baselinesForPolicy returns a request for every Baseline when the cluster
+// policy changes
+func (r *BaselineReconciler) baselinesForPolicy(o client.Object) []reconcile.Request {
+	if o.GetName() != perfv1.PolicyName {
+		return nil
+	}
+	return r.allBaselines()
+}
+
+// halt scales the workload of the Baseline to zero while the cluster policy
+// halts every Baseline
+func (r *BaselineReconciler) halt(ctx context.Context, b *perfv1.Baseline) (ctrl.Result, error) {
+	if _, err := r.stopWorkload(ctx, b); err != nil {
+		return ctrl.Result{}, err
+	}
+	if !meta.IsStatusConditionTrue(b.Status.Conditions, perfv1.ConditionHalted) {
+		r.recorder.Event(b, "Warning", "Halted", "Workload stopped by the cluster policy")
+	}
+	b.Status.Command = ""
+	b.Status.Custom = ""
+	b.Status.ControlPlaneCommand = ""
+	return ctrl.Result{}, r.updateCondition(ctx, b, metav1.Condition{
+		Type:    perfv1.ConditionHalted,
+		Status:  metav1.ConditionTrue,
+		Reason:  "PolicyHalt",
+		Message: fmt.Sprintf("The BaselinePolicy %s halts every Baseline", perfv1.PolicyName),
+	})
+}
+
+// policyViolations returns why the Baseline exceeds the limits of the policy
+func policyViolations(policy *perfv1.BaselinePolicy, b *perfv1.Baseline) []string {
+	var violations []string
+	if max := policy.Spec.MaxCpuPerNode; max != nil && b.Spec.Cpu != nil {
+		if *b.Spec.Cpu == 0 {
+			violations = append(violations, fmt.Sprintf("cpu 0 runs a worker per online cpu, exceeding maxCpuPerNode %d", *max))
+		} else if *b.Spec.Cpu > *max {
+			violations = append(violations, fmt.Sprintf("cpu %d exceeds maxCpuPerNode %d", *b.Spec.Cpu, *max))
+		}
+	}
+	if max := policy.Spec.MaxMemoryPerNode; max != nil && b.Spec.Memory != "" {
+		if mem, ok := perfv1.ParseSize(b.Spec.Memory); !ok {
+			violations = append(violations, fmt.Sprintf("mem %s can not be checked against maxMemoryPerNode %s", b.Spec.Memory, max.String()))
+		} else if resource.NewQuantity(mem, resource.BinarySI).Cmp(*max) > 0 {
+			violations = append(violations, fmt.Sprintf("mem %s exceeds maxMemoryPerNode %s", b.Spec.Memory, max.String()))
+		}
+	}
+	return violations
+}
+
+// forbiddenNodeRequirements returns the node requirements keeping the
+// Baseline away from the nodes forbidden by the policy
+func forbiddenNodeRequirements(policy *perfv1.BaselinePolicy, b *perfv1.Baseline) []corev1.NodeSelectorRequirement {
+	var requirements []corev1.NodeSelectorRequirement
+	for _, forbidden := range policy.Spec.ForbiddenNodeLabels {
+		if contains(forbidden.AllowedNamespaces, b.Namespace) {
+			continue
+		}
+		requirements = append(requirements, corev1.NodeSelectorRequirement{
+			Key:      forbidden.Key,
+			Operator: corev1.NodeSelectorOpDoesNotExist,
+		})
+	}
+	return requirements
+}
+
+// violatesPolicy stops the workload of a Baseline exceeding the policy limits
+func (r *BaselineReconciler) violatesPolicy(ctx context.Context, b *perfv1.Baseline, violations []string) (ctrl.Result, error) {
+	if _, err := r.stopWorkload(ctx, b); err != nil {
+		return ctrl.Result{}, err
+	}
+	message := strings.Join(violations, ", ")
+	if !meta.IsStatusConditionTrue(b.Status.Conditions, perfv1.ConditionDegraded) {
+		r.recorder.Event(b, "Warning", "PolicyViolation", message)
+	}
+	b.Status.Command = ""
+	b.Status.Custom = ""
+	b.Status.ControlPlaneCommand = ""
+	return ctrl.Result{}, r.updateCondition(ctx, b, metav1.Condition{
+		Type:    perfv1.ConditionDegraded,
+		Status:  metav1.ConditionTrue,
+		Reason:  "PolicyViolation",
+		Message: message,
+	})
+}
+
+// contains returns if the list contains the item
+func contains(list []string, item string) bool {
+	for _, i := range list {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
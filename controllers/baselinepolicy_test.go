@@ -0,0 +1,152 @@
+package controllers
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/envtest/komega"
+
+	perfv1 "github.com/josecastillolema/baseline-operator/api/v1"
+)
+
+var _ = Describe("BaselinePolicy", func() {
+
+	const (
+		BaselineName         = "test-policy-baseline"
+		ProfiledBaselineName = "test-policy-profiled-baseline"
+		ProfileName          = "test-policy-profile"
+		BaselineNamespace    = "default"
+	)
+
+	Context("Checking a Baseline against the policy limits", func() {
+		It("Should report the cpu and memory exceeding them", func() {
+			maxCpu := int32(2)
+			maxMem := resource.MustParse("1Gi")
+			policy := &perfv1.BaselinePolicy{Spec: perfv1.BaselinePolicySpec{MaxCpuPerNode: &maxCpu, MaxMemoryPerNode: &maxMem}}
+
+			cpu := int32(2)
+			b := &perfv1.Baseline{Spec: perfv1.BaselineSpec{Cpu: &cpu, Memory: "1G"}}
+			Expect(policyViolations(policy, b)).Should(BeEmpty())
+
+			cpu = 3
+			b.Spec.Memory = "2G"
+			Expect(policyViolations(policy, b)).Should(HaveLen(2))
+
+			cpu = 0
+			b.Spec.Memory = "50%"
+			Expect(policyViolations(policy, b)).Should(HaveLen(2))
+		})
+
+		It("Should keep the Baselines away from the forbidden nodes unless allowed", func() {
+			policy := &perfv1.BaselinePolicy{Spec: perfv1.BaselinePolicySpec{
+				ForbiddenNodeLabels: []perfv1.ForbiddenNodeLabel{{
+					Key:               "node-role.kubernetes.io/control-plane",
+					AllowedNamespaces: []string{"perf"},
+				}},
+			}}
+			b := &perfv1.Baseline{ObjectMeta: metav1.ObjectMeta{Namespace: BaselineNamespace}}
+			Expect(forbiddenNodeRequirements(policy, b)).Should(Equal([]corev1.NodeSelectorRequirement{{
+				Key:      "node-role.kubernetes.io/control-plane",
+				Operator: corev1.NodeSelectorOpDoesNotExist,
+			}}))
+
+			b.Namespace = "perf"
+			Expect(forbiddenNodeRequirements(policy, b)).Should(BeEmpty())
+		})
+	})
+
+	Context("Halting every Baseline", func() {
+		It("Should stop the workload and report Halted until resumed", func() {
+			By("By creating a Baseline")
+			ctx := context.Background()
+			cpu := int32(1)
+			baseline := &perfv1.Baseline{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      BaselineName,
+					Namespace: BaselineNamespace,
+				},
+				Spec: perfv1.BaselineSpec{
+					Cpu: &cpu,
+				},
+			}
+			Expect(k8sClient.Create(ctx, baseline)).Should(Succeed())
+			dsLookupKey := types.NamespacedName{Name: BaselineName, Namespace: BaselineNamespace}
+			Eventually(func() error {
+				return k8sClient.Get(ctx, dsLookupKey, &appsv1.DaemonSet{})
+			}).Should(Succeed())
+
+			By("By halting the cluster policy")
+			policy := &perfv1.BaselinePolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: perfv1.PolicyName},
+				Spec:       perfv1.BaselinePolicySpec{Halt: true},
+			}
+			Expect(k8sClient.Create(ctx, policy)).Should(Succeed())
+			Eventually(func() bool {
+				err := k8sClient.Get(ctx, dsLookupKey, &appsv1.DaemonSet{})
+				return errors.IsNotFound(err)
+			}).Should(BeTrue())
+			Eventually(func() bool {
+				Expect(k8sClient.Get(ctx, dsLookupKey, baseline)).Should(Succeed())
+				return meta.IsStatusConditionTrue(baseline.Status.Conditions, perfv1.ConditionHalted)
+			}).Should(BeTrue())
+			Expect(baseline.Status.Command).Should(BeEmpty())
+
+			By("By resuming the cluster policy")
+			Expect(k8sClient.Delete(ctx, policy)).Should(Succeed())
+			Eventually(komega.Object(baseline)).Should(HaveField("Status.Command", Equal("stress-ng -t 0 --cpu 1")))
+			Expect(meta.IsStatusConditionFalse(baseline.Status.Conditions, perfv1.ConditionHalted)).Should(BeTrue())
+		})
+
+		It("Should stop the workload of a Baseline whose profile is missing", func() {
+			By("By creating a profile and a Baseline referencing it")
+			ctx := context.Background()
+			cpu := int32(1)
+			profile := &perfv1.BaselineProfile{
+				ObjectMeta: metav1.ObjectMeta{Name: ProfileName},
+				Spec:       perfv1.BaselineProfileSpec{Cpu: &cpu},
+			}
+			Expect(k8sClient.Create(ctx, profile)).Should(Succeed())
+			baseline := &perfv1.Baseline{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      ProfiledBaselineName,
+					Namespace: BaselineNamespace,
+				},
+				Spec: perfv1.BaselineSpec{
+					ProfileRef: &perfv1.ProfileReference{Name: ProfileName},
+				},
+			}
+			Expect(k8sClient.Create(ctx, baseline)).Should(Succeed())
+			dsLookupKey := types.NamespacedName{Name: ProfiledBaselineName, Namespace: BaselineNamespace}
+			Eventually(func() error {
+				return k8sClient.Get(ctx, dsLookupKey, &appsv1.DaemonSet{})
+			}).Should(Succeed())
+
+			By("By deleting the profile and halting the cluster policy")
+			Expect(k8sClient.Delete(ctx, profile)).Should(Succeed())
+			policy := &perfv1.BaselinePolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: perfv1.PolicyName},
+				Spec:       perfv1.BaselinePolicySpec{Halt: true},
+			}
+			Expect(k8sClient.Create(ctx, policy)).Should(Succeed())
+			Eventually(func() bool {
+				err := k8sClient.Get(ctx, dsLookupKey, &appsv1.DaemonSet{})
+				return errors.IsNotFound(err)
+			}).Should(BeTrue())
+			Eventually(func() bool {
+				Expect(k8sClient.Get(ctx, dsLookupKey, baseline)).Should(Succeed())
+				return meta.IsStatusConditionTrue(baseline.Status.Conditions, perfv1.ConditionHalted)
+			}).Should(BeTrue())
+
+			Expect(k8sClient.Delete(ctx, policy)).Should(Succeed())
+			Expect(k8sClient.Delete(ctx, baseline)).Should(Succeed())
+		})
+	})
+})
@@ -0,0 +1,106 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	perfv1 "github.com/josecastillolema/baseline-operator/api/v1"
+)
+
+// profileRefField indexes the Baselines by the name of their profile
+const profileRefField = ".spec.profileRef.name"
+
+// indexProfileRef returns the profile referenced by a Baseline, if any
+func indexProfileRef(o client.Object) []string {
+	b := o.(*perfv1.Baseline)
+	if b.Spec.ProfileRef == nil {
+		return nil
+	}
+	return []string{b.Spec.ProfileRef.Name}
+}
+
+// baselinesForProfile returns a request for every Baseline referencing the
+// given BaselineProfile, so they are re-rolled when the profile changes
+func (r *BaselineReconciler) baselinesForProfile(o client.Object) []reconcile.Request {
+	baselines := &perfv1.BaselineList{}
+	err := r.List(context.Background(), baselines, client.MatchingFields{profileRefField: o.GetName()})
+	if err != nil {
+		ctrllog.Log.Error(err, "Failed to list the Baselines of the BaselineProfile", "BaselineProfile.Name", o.GetName())
+		return nil
+	}
+	requests := make([]reconcile.Request, len(baselines.Items))
+	for i, b := range baselines.Items {
+		requests[i] = reconcile.Request{NamespacedName: types.NamespacedName{Name: b.Name, Namespace: b.Namespace}}
+	}
+	return requests
+}
+
+// applyProfile fills the fields the Baseline does not set with the ones of
+// the profile. A field set to zero, false or an empty list in the Baseline
+// overrides the profile, except the strings, which are unset when empty.
+// The node selectors are merged, the Baseline labels winning
+func applyProfile(spec *perfv1.BaselineSpec, profile *perfv1.BaselineProfileSpec) {
+	if spec.Cpu == nil && profile.Cpu != nil {
+		cpu := *profile.Cpu
+		spec.Cpu = &cpu
+	}
+	if spec.Memory == "" {
+		spec.Memory = profile.Memory
+	}
+	if spec.Io == nil && profile.Io != nil {
+		io := *profile.Io
+		spec.Io = &io
+	}
+	if spec.Sock == nil && profile.Sock != nil {
+		sock := *profile.Sock
+		spec.Sock = &sock
+	}
+	if spec.SockInterface == "" {
+		spec.SockInterface = profile.SockInterface
+	}
+	if spec.Custom == "" && spec.CustomArgs == nil {
+		spec.CustomArgs = append([]string(nil), profile.CustomArgs...)
+	}
+	if spec.Image == "" {
+		spec.Image = profile.Image
+	}
+	if spec.HostNetwork == nil && profile.HostNetwork != nil {
+		hostNetwork := *profile.HostNetwork
+		spec.HostNetwork = &hostNetwork
+	}
+	if len(profile.NodeSelector) != 0 {
+		nodeSelector := make(map[string]string, len(profile.NodeSelector)+len(spec.NodeSelector))
+		for k, v := range profile.NodeSelector {
+			nodeSelector[k] = v
+		}
+		for k, v := range spec.NodeSelector {
+			nodeSelector[k] = v
+		}
+		spec.NodeSelector = nodeSelector
+	}
+	if spec.Tolerations == nil && len(profile.Tolerations) != 0 {
+		for _, t := range profile.Tolerations {
+			spec.Tolerations = append(spec.Tolerations, *t.DeepCopy())
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package controllers
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/envtest/komega"
+
+	perfv1 "github.com/josecastillolema/baseline-operator/api/v1"
+)
+
+var _ = Describe("BaselineProfile", func() {
+
+	const (
+		ProfileName       = "test-profile"
+		BaselineName      = "test-profiled-baseline"
+		ImageProfileName  = "test-image-profile"
+		DegradedName      = "test-degraded-baseline"
+		BaselineNamespace = "default"
+	)
+
+	Context("Merging a profile into a Baseline", func() {
+		It("Should only fill the fields the Baseline does not set", func() {
+			cpu, io := int32(4), int32(2)
+			spec := perfv1.BaselineSpec{
+				Memory:       "2G",
+				NodeSelector: map[string]string{"stress": "false"},
+			}
+			applyProfile(&spec, &perfv1.BaselineProfileSpec{
+				Cpu:          &cpu,
+				Memory:       "1G",
+				Io:           &io,
+				CustomArgs:   []string{"--timer", "1"},
+				NodeSelector: map[string]string{"stress": "true", "zone": "a"},
+				Tolerations:  []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpExists}},
+			})
+
+			Expect(*spec.Cpu).Should(Equal(int32(4)))
+			Expect(spec.Memory).Should(Equal("2G"))
+			Expect(*spec.Io).Should(Equal(int32(2)))
+			Expect(spec.CustomArgs).Should(Equal([]string{"--timer", "1"}))
+			Expect(spec.NodeSelector).Should(Equal(map[string]string{"stress": "false", "zone": "a"}))
+			Expect(spec.Tolerations).Should(HaveLen(1))
+		})
+
+		It("Should let the Baseline override the profile with zero, false or an empty list", func() {
+			io, sock, noWorkers := int32(2), int32(1), int32(0)
+			hostNetwork, noHostNetwork := true, false
+			spec := perfv1.BaselineSpec{
+				Io:          &noWorkers,
+				Sock:        &noWorkers,
+				HostNetwork: &noHostNetwork,
+				CustomArgs:  []string{},
+				Tolerations: []corev1.Toleration{},
+			}
+			applyProfile(&spec, &perfv1.BaselineProfileSpec{
+				Io:          &io,
+				Sock:        &sock,
+				HostNetwork: &hostNetwork,
+				CustomArgs:  []string{"--timer", "1"},
+				Tolerations: []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpExists}},
+			})
+
+			Expect(spec.IoWorkers()).Should(BeZero())
+			Expect(spec.SockWorkers()).Should(BeZero())
+			Expect(spec.UsesHostNetwork()).Should(BeFalse())
+			Expect(spec.CustomArgs).Should(BeEmpty())
+			Expect(spec.Tolerations).Should(BeEmpty())
+		})
+	})
+
+	Context("Updating a BaselineProfile", func() {
+		It("Should re-roll the Baselines referencing it", func() {
+			By("By creating a profile and a Baseline referencing it")
+			ctx := context.Background()
+			cpu, profileIo, io := int32(1), int32(1), int32(2)
+			profile := &perfv1.BaselineProfile{
+				ObjectMeta: metav1.ObjectMeta{Name: ProfileName},
+				Spec: perfv1.BaselineProfileSpec{
+					Cpu: &cpu,
+					Io:  &profileIo,
+				},
+			}
+			Expect(k8sClient.Create(ctx, profile)).Should(Succeed())
+			baseline := &perfv1.Baseline{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      BaselineName,
+					Namespace: BaselineNamespace,
+				},
+				Spec: perfv1.BaselineSpec{
+					Io:         &io,
+					ProfileRef: &perfv1.ProfileReference{Name: ProfileName},
+				},
+			}
+			Expect(k8sClient.Create(ctx, baseline)).Should(Succeed())
+			Eventually(komega.Object(baseline)).Should(HaveField("Status.Command", Equal("stress-ng -t 0 --cpu 1 --io 2")))
+
+			By("By updating the profile")
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: ProfileName}, profile)).Should(Succeed())
+			*profile.Spec.Cpu = 3
+			Expect(k8sClient.Update(ctx, profile)).Should(Succeed())
+			Eventually(komega.Object(baseline)).Should(HaveField("Status.Command", Equal("stress-ng -t 0 --cpu 3 --io 2")))
+		})
+	})
+
+	Context("Reporting a condition of a profiled Baseline", func() {
+		It("Should keep rendering the DaemonSet from the profile", func() {
+			By("By creating a profile with an image and a Baseline referencing it")
+			ctx := context.Background()
+			cpu := int32(2)
+			profile := &perfv1.BaselineProfile{
+				ObjectMeta: metav1.ObjectMeta{Name: ImageProfileName},
+				Spec: perfv1.BaselineProfileSpec{
+					Cpu:   &cpu,
+					Image: "quay.io/cloud-bulldozer/stressng:profile",
+				},
+			}
+			Expect(k8sClient.Create(ctx, profile)).Should(Succeed())
+			baseline := &perfv1.Baseline{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      DegradedName,
+					Namespace: BaselineNamespace,
+				},
+				Spec: perfv1.BaselineSpec{
+					ProfileRef: &perfv1.ProfileReference{Name: ImageProfileName},
+				},
+			}
+			Expect(k8sClient.Create(ctx, baseline)).Should(Succeed())
+			ds := &appsv1.DaemonSet{ObjectMeta: metav1.ObjectMeta{Name: DegradedName, Namespace: BaselineNamespace}}
+			Eventually(komega.Object(ds)).Should(HaveField("Spec.Template.Spec.Containers", ConsistOf(And(
+				HaveField("Image", "quay.io/cloud-bulldozer/stressng:profile"),
+				HaveField("Args", Equal([]string{"stress-ng", "-t", "0", "--cpu", "2"})),
+			))))
+
+			By("By degrading the Baseline with a control plane toleration")
+			Eventually(komega.Update(baseline, func() {
+				baseline.Spec.Tolerations = []corev1.Toleration{{Key: "node-role.kubernetes.io/master", Operator: corev1.TolerationOpExists}}
+			})).Should(Succeed())
+			Eventually(komega.Object(baseline)).Should(HaveField("Status.Conditions",
+				ContainElement(And(HaveField("Type", perfv1.ConditionDegraded), HaveField("Status", metav1.ConditionTrue)))))
+			Eventually(komega.Object(ds)).Should(HaveField("Spec.Template.Spec.Tolerations", HaveLen(1)))
+			Consistently(komega.Object(ds), "2s").Should(HaveField("Spec.Template.Spec.Containers", ConsistOf(And(
+				HaveField("Image", "quay.io/cloud-bulldozer/stressng:profile"),
+				HaveField("Args", Equal([]string{"stress-ng", "-t", "0", "--cpu", "2"})),
+			))))
+
+			Expect(k8sClient.Delete(ctx, baseline)).Should(Succeed())
+			Expect(k8sClient.Delete(ctx, profile)).Should(Succeed())
+		})
+	})
+})
@@ -0,0 +1,78 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	perfv1 "github.com/josecastillolema/baseline-operator/api/v1"
+)
+
+var (
+	// fioIOPS are the operations per second of the last fio run per node
+	fioIOPS = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "baseline_fio_iops",
+		Help: "Operations per second of the last fio run of a Baseline on a node",
+	}, []string{"namespace", "baseline", "node", "direction"})
+	// fioLatency is the mean latency of the last fio run per node
+	fioLatency = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "baseline_fio_latency_seconds",
+		Help: "Mean latency of the last fio run of a Baseline on a node",
+	}, []string{"namespace", "baseline", "node", "direction"})
+	// iperf3Bitrate is the received bitrate of the last iperf3 test per pair
+	iperf3Bitrate = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "baseline_iperf3_bits_per_second",
+		Help: "Received bitrate of the last iperf3 test of a Baseline between two nodes",
+	}, []string{"namespace", "baseline", "source", "destination"})
+	// churnLatency is the creation-to-running latency of the churn pods
+	churnLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "baseline_churn_pod_startup_seconds",
+		Help:    "Latency from the creation of a churn pod of a Baseline until it runs",
+		Buckets: prometheus.ExponentialBuckets(0.25, 2, 10),
+	}, []string{"namespace", "baseline"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(fioIOPS, fioLatency, iperf3Bitrate, churnLatency)
+}
+
+// setFioMetrics replaces the fio metrics of the Baseline by the results
+func setFioMetrics(b *perfv1.Baseline, results []perfv1.FioResult) {
+	for _, old := range b.Status.FioResults {
+		for _, direction := range []string{"read", "write"} {
+			fioIOPS.DeleteLabelValues(b.Namespace, b.Name, old.Node, direction)
+			fioLatency.DeleteLabelValues(b.Namespace, b.Name, old.Node, direction)
+		}
+	}
+	for _, r := range results {
+		fioIOPS.WithLabelValues(b.Namespace, b.Name, r.Node, "read").Set(float64(r.ReadIOPS))
+		fioIOPS.WithLabelValues(b.Namespace, b.Name, r.Node, "write").Set(float64(r.WriteIOPS))
+		fioLatency.WithLabelValues(b.Namespace, b.Name, r.Node, "read").Set(r.ReadLatency.Seconds())
+		fioLatency.WithLabelValues(b.Namespace, b.Name, r.Node, "write").Set(r.WriteLatency.Seconds())
+	}
+}
+
+// setIperf3Metrics replaces the iperf3 metrics of the Baseline by the results
+func setIperf3Metrics(b *perfv1.Baseline, results []perfv1.Iperf3Result) {
+	for _, old := range b.Status.Iperf3Results {
+		iperf3Bitrate.DeleteLabelValues(b.Namespace, b.Name, old.Source, old.Destination)
+	}
+	for _, r := range results {
+		iperf3Bitrate.WithLabelValues(b.Namespace, b.Name, r.Source, r.Destination).Set(float64(r.BitsPerSecond))
+	}
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// namespaced-rbac splits the ClusterRole generated from the RBAC markers of
+// the operator into a Role per watched namespace, and a ClusterRole limited
+// to the cluster-scoped resources
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// clusterScoped are the cluster-scoped resources the operator reads or
+// manages, by API group
+var clusterScoped = map[string][]string{
+	"":                 {"namespaces", "nodes"},
+	"perf.baseline.io": {"baselinepolicies", "baselineprofiles"},
+}
+
+func main() {
+	var role, output, namespaces, prefix, serviceAccount, namespace string
+	flag.StringVar(&role, "role", "config/rbac/role.yaml", "The ClusterRole generated by controller-gen.")
+	flag.StringVar(&output, "output", "config/namespaced", "The directory the RBAC and the manager patch are written to.")
+	flag.StringVar(&namespaces, "namespaces", "", "The comma-separated namespaces watched by the operator.")
+	flag.StringVar(&prefix, "name-prefix", "baseline-operator-", "The name prefix of the deployment.")
+	flag.StringVar(&namespace, "namespace", "baseline-operator-system", "The namespace the operator is deployed in.")
+	flag.StringVar(&serviceAccount, "service-account", "controller-manager", "The service account of the operator, without prefix.")
+	flag.Parse()
+
+	if namespaces == "" {
+		fmt.Fprintln(os.Stderr, "--namespaces is required")
+		os.Exit(1)
+	}
+	data, err := os.ReadFile(role)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	clusterRole := &rbacv1.ClusterRole{}
+	if err := yaml.Unmarshal(data, clusterRole); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	name := prefix + "manager-role"
+	subject := rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Name: prefix + serviceAccount, Namespace: namespace}
+	namespaced, cluster := splitRules(clusterRole.Rules)
+	objects := []interface{}{
+		&rbacv1.ClusterRole{
+			TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "ClusterRole"},
+			ObjectMeta: metav1.ObjectMeta{Name: prefix + "manager-cluster-role"},
+			Rules:      cluster,
+		},
+		&rbacv1.ClusterRoleBinding{
+			TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "ClusterRoleBinding"},
+			ObjectMeta: metav1.ObjectMeta{Name: prefix + "manager-cluster-rolebinding"},
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: prefix + "manager-cluster-role"},
+			Subjects:   []rbacv1.Subject{subject},
+		},
+	}
+	for _, ns := range strings.Split(namespaces, ",") {
+		ns = strings.TrimSpace(ns)
+		objects = append(objects,
+			&rbacv1.Role{
+				TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "Role"},
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns},
+				Rules:      namespaced,
+			},
+			&rbacv1.RoleBinding{
+				TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "RoleBinding"},
+				ObjectMeta: metav1.ObjectMeta{Name: prefix + "manager-rolebinding", Namespace: ns},
+				RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: name},
+				Subjects:   []rbacv1.Subject{subject},
+			})
+	}
+
+	var rbac []string
+	for _, o := range objects {
+		data, err := yaml.Marshal(o)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		rbac = append(rbac, string(data))
+	}
+	patch := fmt.Sprintf(managerPatch, prefix+"controller-manager", namespace, namespaces)
+	for file, content := range map[string]string{
+		"rbac.yaml":                "---\n" + strings.Join(rbac, "---\n"),
+		"manager_watch_patch.yaml": patch,
+	} {
+		if err := os.WriteFile(filepath.Join(output, file), []byte(content), 0o644); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+}
+
+// managerPatch restricts the manager to the watched namespaces
+const managerPatch = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: %s
+  namespace: %s
+spec:
+  template:
+    spec:
+      containers:
+      - name: manager
+        env:
+        - name: WATCH_NAMESPACE
+          value: %q
+`
+
+// splitRules returns the rules of the namespaced resources and the ones of
+// the cluster-scoped resources. The cluster-scoped resources are only read
+// by an operator restricted to namespaces
+func splitRules(rules []rbacv1.PolicyRule) ([]rbacv1.PolicyRule, []rbacv1.PolicyRule) {
+	var namespaced, cluster []rbacv1.PolicyRule
+	for _, rule := range rules {
+		var namespacedResources, clusterResources []string
+		for _, resource := range rule.Resources {
+			if isClusterScoped(rule.APIGroups, resource) {
+				clusterResources = append(clusterResources, resource)
+			} else {
+				namespacedResources = append(namespacedResources, resource)
+			}
+		}
+		if len(namespacedResources) > 0 {
+			r := *rule.DeepCopy()
+			r.Resources = namespacedResources
+			namespaced = append(namespaced, r)
+		}
+		if len(clusterResources) > 0 {
+			r := *rule.DeepCopy()
+			r.Resources = clusterResources
+			r.Verbs = nil
+			for _, verb := range rule.Verbs {
+				if verb == "get" || verb == "list" || verb == "watch" {
+					r.Verbs = append(r.Verbs, verb)
+				}
+			}
+			cluster = append(cluster, r)
+		}
+	}
+	return namespaced, cluster
+}
+
+// isClusterScoped returns if the resource of any of the API groups is
+// cluster-scoped
+func isClusterScoped(groups []string, resource string) bool {
+	for _, group := range groups {
+		for _, r := range clusterScoped[group] {
+			if r == resource {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -18,7 +18,10 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -26,13 +29,18 @@ import (
 
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
+	configv1alpha1 "github.com/josecastillolema/baseline-operator/api/config/v1alpha1"
 	perfv1 "github.com/josecastillolema/baseline-operator/api/v1"
+	perfv2 "github.com/josecastillolema/baseline-operator/api/v2"
 	"github.com/josecastillolema/baseline-operator/controllers"
+	"github.com/josecastillolema/baseline-operator/pkg/operatorconfig"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -45,6 +53,8 @@ func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
 	utilruntime.Must(perfv1.AddToScheme(scheme))
+	utilruntime.Must(perfv2.AddToScheme(scheme))
+	utilruntime.Must(configv1alpha1.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
 
@@ -52,11 +62,54 @@ func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var defaultImage string
+	var toolsImage string
+	var imageRegistry string
+	var stopTimeout time.Duration
+	var probeImages bool
+	var configFile string
+	var watchNamespaces string
+	var maxConcurrentReconciles int
+	var rateLimiterBaseDelay time.Duration
+	var rateLimiterMaxDelay time.Duration
+	var rateLimiterQPS float64
+	var rateLimiterBurst int
+	var daemonSetCreatesPerMinute int
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.StringVar(&defaultImage, "default-image", perfv1.DefaultImage,
+		"The stress-ng image used by the Baselines that do not define one.")
+	flag.StringVar(&toolsImage, "tools-image", perfv1.DefaultToolsImage,
+		"The image shipping httpbench and apiload, used by the HTTP and APIServer Baselines that do not define one.")
+	flag.StringVar(&imageRegistry, "image-registry", "",
+		"The registry the default images are pulled from instead of their own, i.e. a mirror in disconnected clusters.")
+	flag.DurationVar(&stopTimeout, "stop-timeout", 2*time.Minute,
+		"How long the pods of a deleted Baseline are waited for before releasing it.")
+	flag.BoolVar(&probeImages, "probe-images", true,
+		"Probe the stress-ng images with a Job and reject the Baselines using flags they do not support. "+
+			"Disable it on the clusters where the operator can not run Jobs.")
+	flag.StringVar(&configFile, "config", "",
+		"The operator configuration file. Its settings override the flags, and its images, guardrails and default "+
+			"tolerations are reloaded when it changes.")
+	flag.StringVar(&watchNamespaces, "watch-namespaces", os.Getenv("WATCH_NAMESPACE"),
+		"The comma-separated namespaces whose Baselines are reconciled, all of them if empty. "+
+			"Defaults to the WATCH_NAMESPACE environment variable.")
+	flag.IntVar(&maxConcurrentReconciles, "max-concurrent-reconciles", 1,
+		"How many Baselines are reconciled at once, unless the configuration file sets it.")
+	flag.DurationVar(&rateLimiterBaseDelay, "rate-limiter-base-delay", 5*time.Millisecond,
+		"The delay before retrying a failed reconcile, doubled on each consecutive failure of the same Baseline.")
+	flag.DurationVar(&rateLimiterMaxDelay, "rate-limiter-max-delay", 1000*time.Second,
+		"The maximum delay before retrying a failed reconcile.")
+	flag.Float64Var(&rateLimiterQPS, "rate-limiter-qps", 10,
+		"The reconciles per second shared by all the Baselines.")
+	flag.IntVar(&rateLimiterBurst, "rate-limiter-burst", 100,
+		"The reconciles allowed at once above --rate-limiter-qps.")
+	flag.IntVar(&daemonSetCreatesPerMinute, "daemonset-creates-per-minute", 0,
+		"The DaemonSets created per minute by the operator, i.e. when the Baselines are recreated after a restart. "+
+			"Unlimited if 0.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -65,26 +118,126 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+	options := ctrl.Options{
 		Scheme:                 scheme,
 		MetricsBindAddress:     metricsAddr,
 		Port:                   9443,
 		HealthProbeBindAddress: probeAddr,
 		LeaderElection:         enableLeaderElection,
 		LeaderElectionID:       "f0f894d6.baseline.io",
-	})
+	}
+	config := &configv1alpha1.BaselineOperatorConfig{}
+	var err error
+	if configFile != "" {
+		config, err = operatorconfig.Load(configFile, scheme)
+		if err != nil {
+			setupLog.Error(err, "unable to load the operator configuration", "file", configFile)
+			os.Exit(1)
+		}
+		options, err = options.AndFrom(config)
+		if err != nil {
+			setupLog.Error(err, "unable to load the operator configuration", "file", configFile)
+			os.Exit(1)
+		}
+	}
+	// The flags apply to the images the configuration file does not set
+	withFlags := func(c *configv1alpha1.BaselineOperatorConfig) {
+		if c.DefaultImage == "" {
+			c.DefaultImage = defaultImage
+		}
+		if c.ToolsImage == "" {
+			c.ToolsImage = toolsImage
+		}
+		if c.ImageRegistry == "" {
+			c.ImageRegistry = imageRegistry
+		}
+	}
+	withFlags(config)
+	if config.MaxConcurrentReconciles == 0 {
+		if maxConcurrentReconciles < 1 {
+			setupLog.Error(fmt.Errorf("%d is lower than 1", maxConcurrentReconciles), "invalid --max-concurrent-reconciles")
+			os.Exit(1)
+		}
+		config.MaxConcurrentReconciles = maxConcurrentReconciles
+	}
+	rateLimiter, err := controllers.NewRateLimiter(rateLimiterBaseDelay, rateLimiterMaxDelay, rateLimiterQPS, rateLimiterBurst)
+	if err != nil {
+		setupLog.Error(err, "invalid rate limiter flags")
+		os.Exit(1)
+	}
+	if len(config.WatchNamespaces) == 0 && watchNamespaces != "" {
+		for _, ns := range strings.Split(watchNamespaces, ",") {
+			config.WatchNamespaces = append(config.WatchNamespaces, strings.TrimSpace(ns))
+		}
+		if err := config.Validate(); err != nil {
+			setupLog.Error(err, "invalid --watch-namespaces")
+			os.Exit(1)
+		}
+	}
+	// The cluster-scoped objects are still watched cluster-wide
+	switch len(config.WatchNamespaces) {
+	case 0:
+	case 1:
+		options.Namespace = config.WatchNamespaces[0]
+	default:
+		options.NewCache = cache.MultiNamespacedCacheBuilder(config.WatchNamespaces)
+	}
+
+	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), options)
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
 		os.Exit(1)
 	}
 
-	if err = (&controllers.BaselineReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
-	}).SetupWithManager(mgr); err != nil {
+	kubeClient, err := kubernetes.NewForConfig(mgr.GetConfig())
+	if err != nil {
+		setupLog.Error(err, "unable to create the Kubernetes client")
+		os.Exit(1)
+	}
+
+	defaulter := &perfv1.BaselineDefaulter{Image: config.DefaultImage, ToolsImage: config.ToolsImage, Registry: config.ImageRegistry}
+	reconciler := &controllers.BaselineReconciler{
+		Client:                  mgr.GetClient(),
+		Scheme:                  mgr.GetScheme(),
+		Defaulter:               defaulter,
+		StopTimeout:             stopTimeout,
+		KubeClient:              kubeClient,
+		ProbeImages:             probeImages,
+		MaxConcurrentReconciles: config.MaxConcurrentReconciles,
+		WatchNamespaces:         config.WatchNamespaces,
+		RateLimiter:             rateLimiter,
+		DaemonSetCreates:        controllers.NewDaemonSetCreateLimiter(daemonSetCreatesPerMinute),
+	}
+	reconciler.Configure(config)
+	if err = reconciler.SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Baseline")
 		os.Exit(1)
 	}
+	if configFile != "" {
+		err = mgr.Add(&operatorconfig.Watcher{
+			Path:   configFile,
+			Scheme: scheme,
+			OnChange: func(c *configv1alpha1.BaselineOperatorConfig) {
+				withFlags(c)
+				defaulter.SetImages(c.DefaultImage, c.ToolsImage, c.ImageRegistry)
+				reconciler.Configure(c)
+			},
+		})
+		if err != nil {
+			setupLog.Error(err, "unable to watch the operator configuration", "file", configFile)
+			os.Exit(1)
+		}
+	}
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err = (&perfv1.Baseline{}).SetupWebhookWithManager(mgr, defaulter); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Baseline")
+			os.Exit(1)
+		}
+		if err = (&perfv2.Baseline{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Baseline")
+			os.Exit(1)
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -0,0 +1,224 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package apiload generates a steady control plane load by creating,
+// updating, listing and deleting objects in a sandbox namespace.
+package apiload
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+// Kinds of objects the load is generated with
+const (
+	ConfigMaps = "ConfigMaps"
+	Secrets    = "Secrets"
+	Pods       = "Pods"
+)
+
+// Label marks the objects created by a load generator, its value being the
+// name of the generator
+const Label = "perf.baseline.io/apiload"
+
+// Options configure the generated load
+type Options struct {
+	// Namespace is the sandbox namespace the objects are created in
+	Namespace string
+	// Name prefixes the objects, so several generators share the namespace
+	Name string
+	// QPS is the number of requests sent per second
+	QPS float32
+	// Kinds are the kinds of objects the requests alternate between
+	Kinds []string
+	// Objects is the number of objects of each kind kept in the namespace
+	Objects int
+}
+
+// Metrics of the generated requests
+var (
+	// RequestDuration is the latency of the requests per kind, verb and result
+	RequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "baseline_apiload_request_duration_seconds",
+		Help:    "Latency of the requests sent to the API server",
+		Buckets: prometheus.ExponentialBuckets(0.001, 2, 14),
+	}, []string{"kind", "verb", "code"})
+)
+
+// verbs are the requests sent for every kind, in turn
+var verbs = []string{"create", "update", "list", "delete"}
+
+// Run sends requests at the configured rate until the context is done
+func Run(ctx context.Context, cs kubernetes.Interface, o Options) error {
+	if o.Namespace == "" || o.QPS <= 0 || o.Objects < 1 || len(o.Kinds) == 0 {
+		return fmt.Errorf("a namespace, a positive qps, objects and kinds are required")
+	}
+	for _, kind := range o.Kinds {
+		if kind != ConfigMaps && kind != Secrets && kind != Pods {
+			return fmt.Errorf("unknown kind %q", kind)
+		}
+	}
+
+	limiter := flowcontrol.NewTokenBucketRateLimiter(o.QPS, 1)
+	defer limiter.Stop()
+	g := &generator{cs: cs, o: o, objects: map[string][]string{}}
+	for i := 0; ; i++ {
+		if err := limiter.Wait(ctx); err != nil {
+			// The context is done
+			return nil
+		}
+		kind := o.Kinds[i%len(o.Kinds)]
+		verb := verbs[(i/len(o.Kinds))%len(verbs)]
+		g.send(ctx, kind, verb)
+	}
+}
+
+// generator tracks the objects it keeps in the sandbox namespace
+type generator struct {
+	cs      kubernetes.Interface
+	o       Options
+	objects map[string][]string
+	next    int
+	updates int
+}
+
+// send sends a request and records its latency. Objects are created instead
+// of deleted until the namespace holds enough of them, and updated instead of
+// created from then on, so their number stays steady
+func (g *generator) send(ctx context.Context, kind, verb string) {
+	existing := g.objects[kind]
+	switch {
+	case verb == "create" && len(existing) >= g.o.Objects:
+		verb = "update"
+	case verb == "update" && len(existing) == 0:
+		verb = "create"
+	case verb == "delete" && len(existing) < g.o.Objects:
+		verb = "create"
+	}
+
+	var name string
+	switch verb {
+	case "create":
+		g.next++
+		name = g.o.Name + "-" + strconv.Itoa(g.next)
+	case "update":
+		g.updates++
+		name = existing[g.updates%len(existing)]
+	case "delete":
+		name = existing[0]
+	}
+
+	start := time.Now()
+	err := g.request(ctx, kind, verb, name)
+	if ctx.Err() != nil {
+		return
+	}
+	code := "200"
+	if status, ok := err.(errors.APIStatus); ok {
+		code = strconv.Itoa(int(status.Status().Code))
+	} else if err != nil {
+		code = "error"
+	}
+	RequestDuration.WithLabelValues(kind, verb, code).Observe(time.Since(start).Seconds())
+
+	switch {
+	case verb == "create" && err == nil:
+		g.objects[kind] = append(existing, name)
+	case verb == "delete" && (err == nil || errors.IsNotFound(err)):
+		g.objects[kind] = existing[1:]
+	}
+}
+
+// request sends the request of the verb for an object of the kind
+func (g *generator) request(ctx context.Context, kind, verb, name string) error {
+	ns := g.o.Namespace
+	meta := metav1.ObjectMeta{Name: name, Labels: map[string]string{Label: g.o.Name}}
+	selector := metav1.ListOptions{LabelSelector: Label + "=" + g.o.Name}
+	data := map[string]string{"updated": time.Now().Format(time.RFC3339Nano)}
+	var err error
+	switch kind {
+	case ConfigMaps:
+		client := g.cs.CoreV1().ConfigMaps(ns)
+		switch verb {
+		case "create":
+			_, err = client.Create(ctx, &corev1.ConfigMap{ObjectMeta: meta, Data: data}, metav1.CreateOptions{})
+		case "update":
+			_, err = client.Update(ctx, &corev1.ConfigMap{ObjectMeta: meta, Data: data}, metav1.UpdateOptions{})
+		case "list":
+			_, err = client.List(ctx, selector)
+		case "delete":
+			err = client.Delete(ctx, name, metav1.DeleteOptions{})
+		}
+	case Secrets:
+		client := g.cs.CoreV1().Secrets(ns)
+		switch verb {
+		case "create":
+			_, err = client.Create(ctx, &corev1.Secret{ObjectMeta: meta, StringData: data}, metav1.CreateOptions{})
+		case "update":
+			_, err = client.Update(ctx, &corev1.Secret{ObjectMeta: meta, StringData: data}, metav1.UpdateOptions{})
+		case "list":
+			_, err = client.List(ctx, selector)
+		case "delete":
+			err = client.Delete(ctx, name, metav1.DeleteOptions{})
+		}
+	case Pods:
+		client := g.cs.CoreV1().Pods(ns)
+		switch verb {
+		case "create":
+			_, err = client.Create(ctx, pendingPod(meta), metav1.CreateOptions{})
+		case "update":
+			// Only the metadata of a pod can be updated
+			var pod *corev1.Pod
+			pod, err = client.Get(ctx, name, metav1.GetOptions{})
+			if err == nil {
+				pod.Annotations = data
+				_, err = client.Update(ctx, pod, metav1.UpdateOptions{})
+			}
+		case "list":
+			_, err = client.List(ctx, selector)
+		case "delete":
+			grace := int64(0)
+			err = client.Delete(ctx, name, metav1.DeleteOptions{GracePeriodSeconds: &grace})
+		}
+	}
+	return err
+}
+
+// pendingPod returns a pod no node can run, so it loads the API server and
+// the scheduler without running anything
+func pendingPod(meta metav1.ObjectMeta) *corev1.Pod {
+	automount := false
+	return &corev1.Pod{
+		ObjectMeta: meta,
+		Spec: corev1.PodSpec{
+			NodeSelector:                 map[string]string{Label: "unschedulable"},
+			AutomountServiceAccountToken: &automount,
+			Containers: []corev1.Container{{
+				Name:  "pause",
+				Image: "registry.k8s.io/pause:3.7",
+			}},
+		},
+	}
+}
@@ -0,0 +1,50 @@
+package apiload
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("API load", func() {
+
+	Context("Validating the options", func() {
+		It("Should refuse unknown kinds", func() {
+			err := Run(context.Background(), clientset, Options{Namespace: "default", QPS: 1, Objects: 1, Kinds: []string{"Nodes"}})
+			Expect(err).Should(MatchError(ContainSubstring("unknown kind")))
+		})
+	})
+
+	Context("Generating the load", func() {
+		It("Should keep the objects in the sandbox namespace", func() {
+			ctx := context.Background()
+			ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "apiload-sandbox"}}
+			_, err := clientset.CoreV1().Namespaces().Create(ctx, ns, metav1.CreateOptions{})
+			Expect(err).ShouldNot(HaveOccurred())
+
+			runCtx, cancel := context.WithTimeout(ctx, 3*time.Second)
+			defer cancel()
+			err = Run(runCtx, clientset, Options{
+				Namespace: ns.Name,
+				Name:      "test",
+				QPS:       50,
+				Kinds:     []string{ConfigMaps, Secrets},
+				Objects:   5,
+			})
+			Expect(err).ShouldNot(HaveOccurred())
+
+			selector := metav1.ListOptions{LabelSelector: Label + "=test"}
+			cms, err := clientset.CoreV1().ConfigMaps(ns.Name).List(ctx, selector)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(cms.Items).ShouldNot(BeEmpty())
+			Expect(len(cms.Items)).Should(BeNumerically("<=", 5))
+
+			Expect(testutil.CollectAndCount(RequestDuration)).Should(BeNumerically(">=", 8))
+		})
+	})
+})
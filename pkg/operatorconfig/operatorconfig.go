@@ -0,0 +1,126 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package operatorconfig loads the operator configuration file and reloads
+// it when it changes
+package operatorconfig
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	configv1alpha1 "github.com/josecastillolema/baseline-operator/api/config/v1alpha1"
+)
+
+// DefaultInterval is how often the configuration file is checked for changes
+const DefaultInterval = 10 * time.Second
+
+// Load reads the configuration file, rejecting the unknown fields and the
+// invalid values
+func Load(path string, scheme *runtime.Scheme) (*configv1alpha1.BaselineOperatorConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Decode(data, scheme)
+}
+
+// Decode decodes and validates the content of a configuration file
+func Decode(data []byte, scheme *runtime.Scheme) (*configv1alpha1.BaselineOperatorConfig, error) {
+	codecs := serializer.NewCodecFactory(scheme, serializer.EnableStrict)
+	c := &configv1alpha1.BaselineOperatorConfig{}
+	err := runtime.DecodeInto(codecs.UniversalDecoder(configv1alpha1.GroupVersion), data, c)
+	if err != nil {
+		return nil, fmt.Errorf("invalid operator configuration: %w", err)
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Watcher reloads the configuration file when its content changes. The
+// file is polled, as the files of a mounted ConfigMap are replaced through
+// a symlink rather than written
+type Watcher struct {
+	// Path is the path of the configuration file
+	Path string
+	// Scheme decodes the configuration file
+	Scheme *runtime.Scheme
+	// Interval is how often the file is checked, DefaultInterval if unset
+	Interval time.Duration
+	// OnChange is called with the new configuration. An invalid
+	// configuration is logged and the previous one is kept
+	OnChange func(*configv1alpha1.BaselineOperatorConfig)
+
+	last []byte
+}
+
+// Start polls the configuration file until the context is done
+func (w *Watcher) Start(ctx context.Context) error {
+	log := ctrllog.FromContext(ctx).WithName("operatorconfig")
+	interval := w.Interval
+	if interval == 0 {
+		interval = DefaultInterval
+	}
+	// The configuration loaded at boot is the one found when starting
+	w.last, _ = os.ReadFile(w.Path)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			w.check(log)
+		}
+	}
+}
+
+// check reloads the configuration file if its content changed
+func (w *Watcher) check(log logr.Logger) {
+	data, err := os.ReadFile(w.Path)
+	if err != nil {
+		log.Error(err, "Failed to read the operator configuration", "Path", w.Path)
+		return
+	}
+	if bytes.Equal(data, w.last) {
+		return
+	}
+	w.last = data
+	c, err := Decode(data, w.Scheme)
+	if err != nil {
+		log.Error(err, "Ignoring the invalid operator configuration", "Path", w.Path)
+		return
+	}
+	log.Info("Reloading the operator configuration", "Path", w.Path)
+	w.OnChange(c)
+}
+
+// NeedLeaderElection reloads the configuration in every replica, as the
+// webhooks are served by all of them
+func (w *Watcher) NeedLeaderElection() bool {
+	return false
+}
@@ -0,0 +1,96 @@
+package operatorconfig
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+
+	configv1alpha1 "github.com/josecastillolema/baseline-operator/api/config/v1alpha1"
+)
+
+var _ = Describe("Operator configuration", func() {
+
+	scheme := runtime.NewScheme()
+	utilruntime.Must(configv1alpha1.AddToScheme(scheme))
+
+	Context("Loading the configuration file", func() {
+		It("Should load the one shipped with the manager", func() {
+			c, err := Load("../../config/manager/controller_manager_config.yaml", scheme)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(*c.LeaderElection.LeaderElect).Should(BeTrue())
+			Expect(c.Webhook.Port).Should(HaveValue(Equal(9443)))
+		})
+
+		It("Should decode the operator settings", func() {
+			c, err := Decode([]byte(`apiVersion: config.baseline.io/v1alpha1
+kind: BaselineOperatorConfig
+defaultImage: registry.local/stressng:latest
+watchNamespaces: [perf, perf-2]
+maxConcurrentReconciles: 4
+guardrails:
+  maxCpuPerNode: 8
+defaultTolerations:
+- key: node-role.kubernetes.io/infra
+  operator: Exists
+`), scheme)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(c.DefaultImage).Should(Equal("registry.local/stressng:latest"))
+			Expect(c.WatchNamespaces).Should(Equal([]string{"perf", "perf-2"}))
+			Expect(c.MaxConcurrentReconciles).Should(Equal(4))
+			Expect(c.Guardrails.MaxCpuPerNode).Should(HaveValue(Equal(int32(8))))
+			Expect(c.DefaultTolerations).Should(HaveLen(1))
+		})
+
+		It("Should reject the unknown fields and the invalid values", func() {
+			_, err := Decode([]byte(`apiVersion: config.baseline.io/v1alpha1
+kind: BaselineOperatorConfig
+defaultImages: registry.local/stressng:latest
+`), scheme)
+			Expect(err).Should(MatchError(ContainSubstring("defaultImages")))
+
+			_, err = Decode([]byte(`apiVersion: config.baseline.io/v1alpha1
+kind: BaselineOperatorConfig
+watchNamespaces: [Perf, perf, perf]
+maxConcurrentReconciles: -1
+defaultTolerations:
+- operator: Equal
+- key: node-role.kubernetes.io/master
+  operator: Exists
+`), scheme)
+			Expect(err).Should(MatchError(ContainSubstring("watchNamespaces[0]")))
+			Expect(err).Should(MatchError(ContainSubstring("watchNamespaces[2]: Duplicate value")))
+			Expect(err).Should(MatchError(ContainSubstring("maxConcurrentReconciles")))
+			Expect(err).Should(MatchError(ContainSubstring("defaultTolerations[0].operator")))
+			Expect(err).Should(MatchError(ContainSubstring("defaultTolerations[1].key")))
+		})
+	})
+
+	Context("Watching the configuration file", func() {
+		It("Should reload the valid changes only", func() {
+			path := filepath.Join(GinkgoT().TempDir(), "config.yaml")
+			Expect(os.WriteFile(path, []byte("apiVersion: config.baseline.io/v1alpha1\nkind: BaselineOperatorConfig\n"), 0o600)).Should(Succeed())
+
+			reloaded := make(chan *configv1alpha1.BaselineOperatorConfig, 1)
+			w := &Watcher{Path: path, Scheme: scheme, Interval: 10 * time.Millisecond,
+				OnChange: func(c *configv1alpha1.BaselineOperatorConfig) { reloaded <- c }}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go w.Start(ctx)
+			Consistently(reloaded, 50*time.Millisecond).ShouldNot(Receive())
+
+			Expect(os.WriteFile(path, []byte("apiVersion: config.baseline.io/v1alpha1\nkind: BaselineOperatorConfig\nmaxConcurrentReconciles: -1\n"), 0o600)).Should(Succeed())
+			Consistently(reloaded, 50*time.Millisecond).ShouldNot(Receive())
+
+			Expect(os.WriteFile(path, []byte("apiVersion: config.baseline.io/v1alpha1\nkind: BaselineOperatorConfig\ndefaultImage: registry.local/stressng\n"), 0o600)).Should(Succeed())
+			var c *configv1alpha1.BaselineOperatorConfig
+			Eventually(reloaded).Should(Receive(&c))
+			Expect(c.DefaultImage).Should(Equal("registry.local/stressng"))
+		})
+	})
+})